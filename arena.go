@@ -0,0 +1,164 @@
+package mtg
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// arenaLineRe matches a card line in an MTG Arena export, such as
+// "4 Lightning Bolt (M21) 159". The set code and collector number are
+// optional.
+var arenaLineRe = regexp.MustCompile(`^(\d+) (.+?)(?: \(([A-Za-z0-9]+)\)(?: (\S+))?)?$`)
+
+// NewDeckFromArena creates a new deck from the provided reader, which should
+// provide a decklist exported from MTG Arena. Cards are listed under "Deck"
+// and "Sideboard" headers; if the headers are missing, a blank line separates
+// the main deck from the sideboard. The cards under "Commander" and
+// "Companion" headers become the deck's commander and companion, as with
+// the "COMMANDER:" and "COMPANION:" prefixes of the .dec format. Set codes
+// and collector numbers are ignored, and cards are looked up by name using
+// DefaultProvider.
+func NewDeckFromArena(r io.Reader) (Deck, error) {
+	list, err := parseArena(r)
+	if err != nil {
+		return Deck{}, err
+	}
+	return list.resolve(context.Background(), DefaultProvider, nil)
+}
+
+// arenaSection identifies the section of an MTG Arena export that a card
+// line belongs to.
+type arenaSection int
+
+const (
+	arenaDeck arenaSection = iota
+	arenaSideboard
+	arenaCommander
+	arenaCompanion
+)
+
+func parseArena(r io.Reader) (*deckList, error) {
+	var (
+		list    = newDeckList()
+		section = arenaDeck
+	)
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch strings.ToLower(line) {
+		case "":
+			switch {
+			case section == arenaCommander, section == arenaCompanion:
+				section = arenaDeck
+			case len(list.mainOrder) > 0:
+				section = arenaSideboard
+			}
+			continue
+		case "deck":
+			section = arenaDeck
+			continue
+		case "sideboard":
+			section = arenaSideboard
+			continue
+		case "commander":
+			section = arenaCommander
+			continue
+		case "companion":
+			section = arenaCompanion
+			continue
+		}
+
+		count, cardName, err := parseArenaLine(line)
+		if err != nil {
+			return nil, ParseError{lineNum, scanner.Text()}
+		}
+
+		switch section {
+		case arenaCommander:
+			list.commander = cardName
+		case arenaCompanion:
+			list.companion = cardName
+		default:
+			list.add(cardName, count, section == arenaSideboard)
+		}
+	}
+
+	return list, scanner.Err()
+}
+
+func parseArenaLine(line string) (int, string, error) {
+	matches := arenaLineRe.FindStringSubmatch(line)
+	if matches == nil {
+		return 0, "", ParseError{Text: line}
+	}
+
+	n, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return 0, "", ParseError{Text: line}
+	}
+
+	return n, matches[2], nil
+}
+
+// WriteArena writes the deck to w in the format MTG Arena accepts for
+// importing. Each card is written with its set code and collector number
+// when they are known, and without them otherwise. The commander and
+// companion, if any, are written first under their own headers.
+func (d Deck) WriteArena(w io.Writer) (int64, error) {
+	var commander, companion []DeckEntry
+	if d.Commander != nil {
+		commander = []DeckEntry{{*d.Commander, 1}}
+	}
+	if d.Companion != nil {
+		companion = []DeckEntry{{*d.Companion, 1}}
+	}
+
+	var total int64
+	for _, section := range []struct {
+		header  string
+		entries []DeckEntry
+	}{
+		{"Commander", commander},
+		{"Companion", companion},
+		{"Deck", d.MainEntries()},
+		{"Sideboard", d.SideboardEntries()},
+	} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		header := section.header + "\n"
+		if total > 0 {
+			header = "\n" + header
+		}
+		n, err := io.WriteString(w, header)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+		for _, entry := range section.entries {
+			n, err := io.WriteString(w, arenaLine(entry)+"\n")
+			total += int64(n)
+			if err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, nil
+}
+
+func arenaLine(entry DeckEntry) string {
+	line := fmt.Sprintf("%d %s", entry.Count, entry.Card.Name)
+	if entry.Card.SetCode != "" {
+		line += " (" + entry.Card.SetCode + ")"
+		if entry.Card.Number != "" {
+			line += " " + entry.Card.Number
+		}
+	}
+	return line
+}
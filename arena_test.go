@@ -0,0 +1,59 @@
+package mtg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewDeckFromArenaCommander(t *testing.T) {
+	defer useStubProvider()()
+
+	f, err := os.Open(filepath.Join("testdata", "commander.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	deck, err := NewDeckFromArena(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deck.Commander == nil || deck.Commander.Name != "Omnath, Locus of Mana" {
+		t.Errorf("got commander %v, want Omnath, Locus of Mana", deck.Commander)
+	}
+	if deck.Companion == nil || deck.Companion.Name != "Yorion, Sky Nomad" {
+		t.Errorf("got companion %v, want Yorion, Sky Nomad", deck.Companion)
+	}
+	if got := deck.Size(); got != 99 {
+		t.Errorf("main deck has %d cards, want 99", got)
+	}
+	if got := deck.SideboardSize(); got != 1 {
+		t.Errorf("sideboard has %d cards, want 1", got)
+	}
+
+	var buf strings.Builder
+	if _, err := deck.WriteArena(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "Commander\n1 Omnath, Locus of Mana\n\nCompanion\n1 Yorion, Sky Nomad\n\n" +
+		"Deck\n1 Llanowar Elves\n98 Forest\n\nSideboard\n1 Yorion, Sky Nomad\n"
+	if buf.String() != want {
+		t.Errorf("WriteArena wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestDetectArenaCommander(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "commander.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := detectFormat(data)(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.commander != "Omnath, Locus of Mana" {
+		t.Errorf("got commander %q, want Omnath, Locus of Mana", list.commander)
+	}
+}
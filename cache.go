@@ -0,0 +1,243 @@
+package mtg
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Cache stores cards by name. It must be safe for concurrent use.
+type Cache interface {
+	// Get returns the card cached under name, if there is one.
+	Get(name string) (Card, bool)
+	// Set caches card under name.
+	Set(name string, card Card)
+	// Delete removes the card cached under name, if there is one.
+	Delete(name string)
+}
+
+var (
+	// CardCache is the cache used by GetCardForName. It defaults to a
+	// MemoryCache, but can be replaced with any other Cache, such as one
+	// shared between processes, or set to nil to disable caching.
+	CardCache Cache = NewMemoryCache()
+
+	// CacheTTL is how long a card stays in a MemoryCache before it is
+	// fetched again. Zero means cached cards never expire.
+	CacheTTL time.Duration
+
+	// CacheCapacity is the maximum number of cards kept in a MemoryCache.
+	// When the cache is full, the least recently used card is evicted to
+	// make room. Zero means the cache is unbounded.
+	CacheCapacity int
+
+	_ Cache = (*MemoryCache)(nil)
+)
+
+// cacheEntry is a cached card along with the time it was fetched.
+type cacheEntry struct {
+	Card    Card      `json:"card"`
+	Fetched time.Time `json:"fetched"`
+
+	name string
+}
+
+// MemoryCache is an in-memory Cache. It evicts the least recently used card
+// once it holds more than CacheCapacity cards, and forgets cards older than
+// CacheTTL.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// order holds *cacheEntry values, most recently used first.
+	order *list.List
+}
+
+// NewMemoryCache returns a new, empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]*list.Element), order: list.New()}
+}
+
+func (c *MemoryCache) Get(name string) (Card, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[name]
+	if !ok {
+		return Card{}, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if CacheTTL > 0 && time.Since(entry.Fetched) > CacheTTL {
+		c.order.Remove(elem)
+		delete(c.entries, name)
+		return Card{}, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.Card, true
+}
+
+func (c *MemoryCache) Set(name string, card Card) {
+	c.set(name, card, time.Now())
+}
+
+func (c *MemoryCache) Delete(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[name]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, name)
+	}
+}
+
+func (c *MemoryCache) set(name string, card Card, fetched time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[name]; ok {
+		elem.Value = &cacheEntry{card, fetched, name}
+		c.order.MoveToFront(elem)
+	} else {
+		c.entries[name] = c.order.PushFront(&cacheEntry{card, fetched, name})
+	}
+	for CacheCapacity > 0 && c.order.Len() > CacheCapacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).name)
+	}
+}
+
+// Clear removes every card from the cache.
+func (c *MemoryCache) Clear() {
+	c.mu.Lock()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.mu.Unlock()
+}
+
+func (c *MemoryCache) snapshot() map[string]cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := make(map[string]cacheEntry, len(c.entries))
+	for name, elem := range c.entries {
+		entries[name] = *elem.Value.(*cacheEntry)
+	}
+	return entries
+}
+
+func cachedCard(name string) (Card, bool) {
+	if CardCache == nil {
+		return Card{}, false
+	}
+	return CardCache.Get(name)
+}
+
+func cacheCard(name string, card Card) {
+	if CardCache != nil {
+		CardCache.Set(name, card)
+	}
+}
+
+// ClearCardCache clears CardCache, the cache used by GetCardForName, if it
+// has a Clear method like MemoryCache does.
+func ClearCardCache() {
+	if c, ok := CardCache.(interface{ Clear() }); ok {
+		c.Clear()
+	}
+	runtime.GC()
+}
+
+// SaveCache writes the contents of CardCache to the file at path as JSON, so
+// that it can be restored later with LoadCache. The time each card was
+// fetched is saved with it, so CacheTTL still applies after loading. Only
+// a MemoryCache can be saved.
+func SaveCache(path string) error {
+	c, ok := CardCache.(*MemoryCache)
+	if !ok {
+		return errors.New("SaveCache: CardCache is not a *MemoryCache")
+	}
+	return c.Save(path)
+}
+
+// Save writes the contents of the cache to the file at path, as described
+// by SaveCache.
+func (c *MemoryCache) Save(path string) error {
+	data, err := json.Marshal(c.snapshot())
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a failed write can't leave a
+	// truncated cache behind.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadCache adds the cards saved by SaveCache in the file at path to
+// CardCache. A missing file is not an error, since it just means there's
+// nothing cached yet. If the file can't be decoded, the cache is left as it
+// was and the error is returned; callers can treat it as a cold start.
+//
+// If CardCache isn't a MemoryCache, the cards are added with Set, and the
+// times they were fetched are lost.
+func LoadCache(path string) error {
+	if c, ok := CardCache.(*MemoryCache); ok {
+		return c.Load(path)
+	}
+	entries, err := readCacheFile(path)
+	if err != nil {
+		return err
+	}
+	for name, entry := range entries {
+		cacheCard(name, entry.Card)
+	}
+	return nil
+}
+
+// Load adds the cards saved in the file at path to the cache, as described
+// by LoadCache.
+func (c *MemoryCache) Load(path string) error {
+	entries, err := readCacheFile(path)
+	if err != nil {
+		return err
+	}
+	for name, entry := range entries {
+		c.set(name, entry.Card, entry.Fetched)
+	}
+	return nil
+}
+
+// readCacheFile reads the cache entries saved in the file at path, skipping
+// any without a card. A missing file has no entries.
+func readCacheFile(path string) (map[string]cacheEntry, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries map[string]cacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	for name, entry := range entries {
+		if entry.Card.Name == "" {
+			delete(entries, name)
+		}
+	}
+	return entries, nil
+}
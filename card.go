@@ -1,26 +1,69 @@
 package mtg
 
-// TODO: add support for analyzing deck statistics, generating random hands, etc.
-
 import (
 	"bytes"
 	"container/list"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"runtime"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/net/html"
 )
 
-const gathererBase = "http://gatherer.wizards.com"
+// colorless is the color code used for cards with no colored mana symbols
+// in their cost.
+const colorless = "C"
 
 var (
-	cardCache = make(map[string]Card)
+	// ErrCardNotFound is returned when a lookup completes successfully but
+	// no card matches. Other errors mean the lookup itself failed, such as
+	// because of a network error.
+	ErrCardNotFound = errors.New("card not found")
+
+	// GathererBaseURL is the base URL of Gatherer, without a trailing
+	// slash. It can be changed to point the package at a mirror or a test
+	// server.
+	GathererBaseURL = "https://gatherer.wizards.com"
+
+	// HTTPClient is the client used for all requests made by this package,
+	// including those to Gatherer. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// PreferredSet is the code of the set, such as "M21", whose printing
+	// GetCardForName picks when a card has been printed more than once. If
+	// it is empty, or the card wasn't printed in that set, the most recent
+	// printing is picked instead.
+	PreferredSet string
+
+	// UserAgent is the User-Agent header sent with every request made by
+	// this package. If empty, Go's default is used.
+	UserAgent = "github.com/dradtke/mtg (+https://github.com/dradtke/mtg)"
+
+	// RequestInterval is the minimum amount of time between the start of
+	// any two requests made by this package, shared across all lookups.
+	// Set it to zero to disable rate limiting.
+	RequestInterval = 250 * time.Millisecond
+
+	// MaxRetries is the number of times a request is retried after a
+	// network error or a 5xx response.
+	MaxRetries = 3
+
+	// RetryBackoff is how long to wait before the first retry. The wait
+	// doubles after each subsequent attempt.
+	RetryBackoff = 500 * time.Millisecond
+
+	nextRequest   time.Time
+	nextRequestMu sync.Mutex
+
 	allColors = []string{"W", "U", "B", "R", "G"}
 )
 
@@ -30,36 +73,539 @@ type Card struct {
 	MultiverseID int
 	// Name is the name of the card.
 	Name string
-	// ManaCost is the mana cost of the card.
+	// ManaCost is the mana cost of the card as a sequence of bracketed
+	// symbols, e.g. "{2}{U}{U}".
 	ManaCost string
 	// ConvertedManaCost is the total converted mana cost of the card.
 	ConvertedManaCost int
 	// Type is the type of the card.
 	Type string
-	// Text is the text of the card.
+	// Text is the rules text of the card. Mana symbols are written in the
+	// same bracketed form as ManaCost, and paragraphs are separated by
+	// newlines.
 	Text string
-	// Rarity is the rarity of the card.
+	// ColorIndicator holds the colors of the card's color indicator, if it
+	// has one, as a string of WUBRG letters such as "G" or "UB".
+	ColorIndicator string
+	// FlavorText is the flavor text of the card. Multiple paragraphs are
+	// separated by newlines.
+	FlavorText string
+	// Power is the power of a creature card. It is a string because some
+	// creatures, such as Tarmogoyf, have a variable power like "*".
+	Power string
+	// Toughness is the toughness of a creature card.
+	Toughness string
+	// Loyalty is the starting loyalty of a planeswalker card, or zero for
+	// other cards.
+	Loyalty int
+	// Set is the name of the expansion the card was printed in. For cards
+	// with multiple printings, this is the set of the printing identified
+	// by MultiverseID, which is the one shown on the Gatherer details page.
+	Set string
+	// SetCode is the short code of the set the card was printed in, such as
+	// "HOU" for Hour of Devastation.
+	SetCode string
+	// Number is the card's collector number within its set. It is a string
+	// because some collector numbers contain letters.
+	Number string
+	// Rarity is the rarity of the card, e.g. "Common" or "Mythic Rare".
 	Rarity string
+	// Artist is the artist of the card. Printings with more than one
+	// artist list them all, separated by ", ".
+	Artist string
+	// Faces holds each face of a card with more than one, such as a
+	// double-faced card, front face first. The other fields describe the
+	// front face, except for split cards; see IsSplit. For single-faced
+	// cards, Faces is left zero. It is an array rather than a slice so
+	// that Cards can still be compared with ==.
+	Faces [2]CardFace
+}
+
+// CardFace is one face of a card with more than one, such as the back of a
+// double-faced card. Its fields have the same meaning as in Card.
+type CardFace struct {
+	Name              string
+	ManaCost          string
+	ConvertedManaCost int
+	Type              string
+	Text              string
+	ColorIndicator    string
+	FlavorText        string
+	Power             string
+	Toughness         string
+	Loyalty           int
+	Artist            string
+}
+
+// IsMultiFaced reports whether the card has more than one face.
+func (c Card) IsMultiFaced() bool {
+	return c.Faces[1].Name != ""
+}
+
+// IsSplit reports whether the card is a split card, such as Fire // Ice,
+// including those with fuse. A split card's Faces hold its two halves,
+// while its own fields describe both halves together: its Name is
+// "Fire // Ice", its ManaCost holds the symbols of both halves, and its
+// ConvertedManaCost is their sum.
+func (c Card) IsSplit() bool {
+	return c.IsMultiFaced() && strings.Contains(c.Name, " // ")
+}
+
+// face returns the face described by the card's own fields.
+func (c Card) face() CardFace {
+	return CardFace{
+		Name:              c.Name,
+		ManaCost:          c.ManaCost,
+		ConvertedManaCost: c.ConvertedManaCost,
+		Type:              c.Type,
+		Text:              c.Text,
+		ColorIndicator:    c.ColorIndicator,
+		FlavorText:        c.FlavorText,
+		Power:             c.Power,
+		Toughness:         c.Toughness,
+		Loyalty:           c.Loyalty,
+		Artist:            c.Artist,
+	}
+}
+
+// setFace sets the card's own fields to describe face.
+func (c *Card) setFace(face CardFace) {
+	c.Name = face.Name
+	c.ManaCost = face.ManaCost
+	c.ConvertedManaCost = face.ConvertedManaCost
+	c.Type = face.Type
+	c.Text = face.Text
+	c.ColorIndicator = face.ColorIndicator
+	c.FlavorText = face.FlavorText
+	c.Power = face.Power
+	c.Toughness = face.Toughness
+	c.Loyalty = face.Loyalty
+	c.Artist = face.Artist
+}
+
+// Equal reports whether c and other represent the same card. Cards are
+// equal if they have the same MultiverseID, or the same name if either
+// MultiverseID is unknown, so the other fields are ignored. This is looser
+// than ==, which is what a map[Card]int uses; Deck's methods use Equal to
+// avoid holding duplicate entries for the same card.
+func (c Card) Equal(other Card) bool {
+	if c.MultiverseID != 0 && other.MultiverseID != 0 {
+		return c.MultiverseID == other.MultiverseID
+	}
+	return c.Name == other.Name
 }
 
-func (c Card) Colors() (colors []string) {
+// Key returns a string that identifies the card: its multiverseid if it's
+// known, and its name otherwise. Cards with the same Key are Equal, but
+// since a card's Key changes once its MultiverseID is filled in, use Equal
+// to compare cards that may have come from different sources.
+func (c Card) Key() string {
+	if c.MultiverseID != 0 {
+		return strconv.Itoa(c.MultiverseID)
+	}
+	return "name:" + c.Name
+}
+
+// Colors returns the colors in the card's mana cost, in WUBRG order. A
+// hybrid symbol such as {G/W} counts toward both of its colors.
+func (c Card) Colors() []string {
+	return symbolColors(manaSymbols(c.ManaCost))
+}
+
+// ColorsWithColorless is like Colors, but returns []string{"C"} for a
+// colorless card instead of an empty slice.
+func (c Card) ColorsWithColorless() []string {
+	if colors := c.Colors(); len(colors) > 0 {
+		return colors
+	}
+	return []string{colorless}
+}
+
+// ColorIdentity returns the card's color identity as used by Commander, in
+// WUBRG order. It includes the colors of every mana symbol in both the mana
+// cost and the rules text, as well as any color indicator, on every face of
+// the card.
+func (c Card) ColorIdentity() []string {
+	faces := []CardFace{c.face()}
+	if c.IsMultiFaced() {
+		faces = c.Faces[:]
+	}
+	var symbols []string
+	for _, face := range faces {
+		symbols = append(symbols, manaSymbols(face.ManaCost)...)
+		symbols = append(symbols, manaSymbols(face.Text)...)
+		for _, color := range face.ColorIndicator {
+			symbols = append(symbols, string(color))
+		}
+	}
+	return symbolColors(symbols)
+}
+
+// colorString joins a list of color letters into a single string in WUBRG
+// order, such as "UB".
+func colorString(colors []string) (s string) {
 	for _, color := range allColors {
-		if strings.Contains(c.ManaCost, color) {
+		for _, c := range colors {
+			if c == color {
+				s += color
+				break
+			}
+		}
+	}
+	return s
+}
+
+// colorComboNames maps color combinations, as returned by colorString, to
+// their names.
+var colorComboNames = map[string]string{
+	"W":     "White",
+	"U":     "Blue",
+	"B":     "Black",
+	"R":     "Red",
+	"G":     "Green",
+	"WU":    "Azorius",
+	"UB":    "Dimir",
+	"BR":    "Rakdos",
+	"RG":    "Gruul",
+	"WG":    "Selesnya",
+	"WB":    "Orzhov",
+	"UR":    "Izzet",
+	"BG":    "Golgari",
+	"WR":    "Boros",
+	"UG":    "Simic",
+	"WUG":   "Bant",
+	"WUB":   "Esper",
+	"UBR":   "Grixis",
+	"BRG":   "Jund",
+	"WRG":   "Naya",
+	"WBG":   "Abzan",
+	"WUR":   "Jeskai",
+	"UBG":   "Sultai",
+	"WBR":   "Mardu",
+	"URG":   "Temur",
+	"WUBR":  "Four-Color",
+	"UBRG":  "Four-Color",
+	"WBRG":  "Four-Color",
+	"WURG":  "Four-Color",
+	"WUBG":  "Four-Color",
+	"WUBRG": "Five-Color",
+}
+
+// ColorComboName returns the name of the given combination of colors, such
+// as "Azorius" for W and U, "Jeskai" for U, R, and W, or "Five-Color" for
+// all five. The colors may be in any order. Single colors are named after
+// the color, four colors are "Four-Color", and an empty or unrecognized set
+// of colors is "Colorless".
+func ColorComboName(colors []string) string {
+	if name, ok := colorComboNames[colorString(colors)]; ok {
+		return name
+	}
+	return "Colorless"
+}
+
+// symbolColors returns the colors represented by a list of mana symbols,
+// in WUBRG order.
+func symbolColors(symbols []string) (colors []string) {
+	m := make(map[string]struct{})
+	for _, symbol := range symbols {
+		for _, part := range strings.Split(symbol, "/") {
+			m[part] = struct{}{}
+		}
+	}
+	for _, color := range allColors {
+		if _, ok := m[color]; ok {
 			colors = append(colors, color)
 		}
 	}
 	return colors
 }
 
-// GetCard retrieves card information from Gatherer given a multiverseid.
+// ManaSymbols returns the symbols in the card's mana cost, in order and
+// without braces, so that "{2}{U}{U}" gives "2", "U", "U". Hybrid and
+// Phyrexian symbols such as {G/W} and {G/P} are single elements.
+func (c Card) ManaSymbols() []string {
+	return manaSymbols(c.ManaCost)
+}
+
+// HasX reports whether the card's mana cost contains an {X}.
+func (c Card) HasX() bool {
+	for _, symbol := range manaSymbols(c.ManaCost) {
+		if symbol == "X" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsLand reports whether the card is a land, based on its type line.
+func (c Card) IsLand() bool {
+	return c.hasType("Land")
+}
+
+// IsBasicLand reports whether the card is a basic land, based on its type
+// line. This includes Wastes and the snow-covered basics, whose type line
+// reads "Basic Snow Land".
+func (c Card) IsBasicLand() bool {
+	return c.hasSupertype("Basic") && c.hasType("Land")
+}
+
+// IsCreature reports whether the card is a creature, based on its type
+// line. Artifact creatures and legendary creatures count.
+func (c Card) IsCreature() bool {
+	return c.hasType("Creature")
+}
+
+// permanentTypes are the card types of cards that enter the battlefield
+// when they resolve.
+var permanentTypes = []string{"Artifact", "Battle", "Creature", "Enchantment", "Land", "Planeswalker"}
+
+// IsPermanent reports whether the card is a permanent card: an artifact,
+// battle, creature, enchantment, land, or planeswalker.
+func (c Card) IsPermanent() bool {
+	for _, t := range permanentTypes {
+		if c.hasType(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSpell reports whether the card is an instant or sorcery, which goes to
+// the graveyard rather than the battlefield when it resolves. This is
+// narrower than Deck.Spells, which also counts noncreature permanents such
+// as artifacts and enchantments.
+func (c Card) IsSpell() bool {
+	return c.hasType("Instant") || c.hasType("Sorcery")
+}
+
+// Supertypes returns the supertypes on the card's type line, such as
+// "Legendary" or "Basic", in the order they appear.
+func (c Card) Supertypes() []string {
+	supertypes, _, _ := parseType(c.Type)
+	return supertypes
+}
+
+// Types returns the card types on the card's type line, such as "Artifact"
+// and "Creature" for an artifact creature, in the order they appear.
+func (c Card) Types() []string {
+	_, types, _ := parseType(c.Type)
+	return types
+}
+
+// Subtypes returns the subtypes on the card's type line, which are the
+// words after the dash, such as "Human" and "Wizard". It returns nil if the
+// card has no subtypes.
+func (c Card) Subtypes() []string {
+	_, _, subtypes := parseType(c.Type)
+	return subtypes
+}
+
+func (c Card) hasSupertype(t string) bool {
+	return containsFold(c.Supertypes(), t)
+}
+
+func (c Card) hasType(t string) bool {
+	return containsFold(c.Types(), t)
+}
+
+// knownSupertypes holds every supertype that can appear on a type line,
+// in lowercase.
+var knownSupertypes = map[string]bool{
+	"basic":     true,
+	"legendary": true,
+	"ongoing":   true,
+	"snow":      true,
+	"world":     true,
+	"elite":     true,
+	"host":      true,
+}
+
+// parseType splits a type line such as "Legendary Creature — Human Wizard"
+// into its supertypes, card types, and subtypes. Gatherer separates the
+// subtypes with an em dash, but a plain hyphen surrounded by spaces is
+// accepted as well. The type line of a card with more than one face, such
+// as "Creature — Giant // Sorcery — Adventure", is parsed one face at a
+// time, and the results are merged without duplicates.
+func parseType(line string) (supertypes, types, subtypes []string) {
+	if halves := strings.Split(line, " // "); len(halves) > 1 {
+		for _, half := range halves {
+			sup, typ, sub := parseType(half)
+			supertypes = appendNew(supertypes, sup...)
+			types = appendNew(types, typ...)
+			subtypes = appendNew(subtypes, sub...)
+		}
+		return supertypes, types, subtypes
+	}
+	left, right := line, ""
+	for _, dash := range []string{"—", " - "} {
+		if i := strings.Index(line, dash); i != -1 {
+			left, right = line[:i], line[i+len(dash):]
+			break
+		}
+	}
+	for _, word := range strings.Fields(left) {
+		if knownSupertypes[strings.ToLower(word)] {
+			supertypes = append(supertypes, word)
+		} else {
+			types = append(types, word)
+		}
+	}
+	subtypes = strings.Fields(right)
+	return supertypes, types, subtypes
+}
+
+// appendNew appends to list each of items that it doesn't already contain,
+// ignoring case.
+func appendNew(list []string, items ...string) []string {
+	for _, item := range items {
+		if !containsFold(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// containsFold reports whether list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasKeyword reports whether the card's rules text contains the keyword
+// kw, such as "Flying" or "Deathtouch", ignoring case. The keyword must
+// appear as whole words, so "Flash" doesn't match "Flashback".
+func (c Card) HasKeyword(kw string) bool {
+	kw = strings.TrimSpace(kw)
+	if kw == "" {
+		return false
+	}
+	re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(kw) + `\b`)
+	return re.MatchString(c.Text)
+}
+
+// ImageURL returns the URL of the card's image on Gatherer, or an empty
+// string if its MultiverseID is unknown.
+func (c Card) ImageURL() string {
+	if c.MultiverseID == 0 {
+		return ""
+	}
+	return fmt.Sprintf(GathererBaseURL+"/Handlers/Image.ashx?multiverseid=%d&type=card", c.MultiverseID)
+}
+
+// manaSymbol converts the alt text of a Gatherer mana symbol image into
+// the canonical text of that symbol, without braces; e.g. "Blue" becomes
+// "U", "10" stays "10", hybrid symbols like "Two or White" become "2/W",
+// and Phyrexian symbols like "Phyrexian Green" become "G/P".
+func manaSymbol(alt string) (string, bool) {
+	if _, err := strconv.Atoi(alt); err == nil {
+		return alt, true
+	}
+	if parts := strings.Split(alt, " or "); len(parts) > 1 {
+		for i, part := range parts {
+			symbol, ok := manaSymbol(strings.TrimSpace(part))
+			if !ok {
+				return "", false
+			}
+			parts[i] = symbol
+		}
+		return strings.Join(parts, "/"), true
+	}
+	if color := strings.TrimPrefix(alt, "Phyrexian "); color != alt {
+		symbol, ok := manaSymbol(color)
+		if !ok {
+			return "", false
+		}
+		return symbol + "/P", true
+	}
+	switch strings.ToUpper(alt) {
+	case "TWO":
+		return "2", true
+	case "VARIABLE COLORLESS":
+		return "X", true
+	case "SNOW":
+		return "S", true
+	case "COLORLESS":
+		// The colorless mana symbol, as opposed to generic mana, which is
+		// written as a number.
+		return colorless, true
+	case "WHITE":
+		return "W", true
+	case "BLUE":
+		return "U", true
+	case "BLACK":
+		return "B", true
+	case "RED":
+		return "R", true
+	case "GREEN":
+		return "G", true
+	}
+	return "", false
+}
+
+// manaSymbols splits a mana cost in canonical form, such as "{2}{U}{U}",
+// into its individual symbols without braces.
+func manaSymbols(cost string) (symbols []string) {
+	for {
+		i := strings.Index(cost, "{")
+		if i == -1 {
+			return symbols
+		}
+		j := strings.Index(cost[i:], "}")
+		if j == -1 {
+			return symbols
+		}
+		symbols = append(symbols, cost[i+1:i+j])
+		cost = cost[i+j+1:]
+	}
+}
+
+// manaValue computes the converted mana cost represented by a list of
+// mana symbols. Hybrid symbols count as their largest half, so {2/W}
+// contributes 2, and X counts as zero.
+func manaValue(symbols []string) (cmc int) {
+	for _, symbol := range symbols {
+		if symbol == "X" {
+			continue
+		}
+		max := 0
+		for _, part := range strings.Split(symbol, "/") {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				n = 1
+			}
+			if n > max {
+				max = n
+			}
+		}
+		cmc += max
+	}
+	return cmc
+}
+
+// FetchCard retrieves card information from Gatherer given a multiverseid.
+// If there's no card with that multiverseid, the error is ErrCardNotFound.
 func FetchCard(multiverseid int) (Card, error) {
-	resp, err := http.Get(fmt.Sprintf(gathererBase+"/Pages/Card/Details.aspx?multiverseid=%d", multiverseid))
+	return FetchCardContext(context.Background(), multiverseid)
+}
+
+// FetchCardContext is like FetchCard, but the request is canceled if ctx
+// is done before it completes.
+func FetchCardContext(ctx context.Context, multiverseid int) (Card, error) {
+	resp, err := get(ctx, fmt.Sprintf(GathererBaseURL+"/Pages/Card/Details.aspx?multiverseid=%d", multiverseid))
 	if err != nil {
 		return Card{}, err
 	}
 	defer resp.Body.Close()
 
-	card, err := parseCard(resp.Body)
+	// Gatherer redirects to its error page for unknown multiverseids.
+	if resp.Request.URL.Path == "/Pages/Error.aspx" {
+		return Card{}, ErrCardNotFound
+	}
+	card, err := ParseCard(resp.Body)
 	if err != nil {
 		return Card{}, err
 	}
@@ -68,16 +614,107 @@ func FetchCard(multiverseid int) (Card, error) {
 	return card, nil
 }
 
-// GetCardForName searches Gatherer for the given card. Errors are only
-// returned when a network  or unexpected error occurs; both return values
-// will be nil if the card was simply not found. An internal cache is used
-// to speed up subsequent calls for the same name.
+// FetchCards retrieves card information from Gatherer for each of the given
+// multiverseids, running up to MaxConcurrentLookups requests at once. The
+// results are keyed by multiverseid. If any of the cards can't be fetched,
+// the error will be of type ErrUnfetchedCards, and the map will still hold
+// every card that was fetched successfully.
+func FetchCards(ids []int) (map[int]Card, error) {
+	return FetchCardsContext(context.Background(), ids)
+}
+
+// FetchCardsContext is like FetchCards, but the requests are canceled if
+// ctx is done before they complete.
+func FetchCardsContext(ctx context.Context, ids []int) (map[int]Card, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		cards    = make(map[int]Card, len(ids))
+		failed   = make(ErrUnfetchedCards)
+		queue    = make(chan int)
+		workers  = MaxConcurrentLookups
+		enqueued = make(map[int]bool, len(ids))
+	)
+	if workers < 1 {
+		workers = 1
+	}
+
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				card, err := FetchCardContext(ctx, id)
+
+				mu.Lock()
+				if err != nil {
+					failed[id] = err
+				} else {
+					cards[id] = card
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range ids {
+		if !enqueued[id] {
+			enqueued[id] = true
+			queue <- id
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return cards, err
+	}
+	if len(failed) > 0 {
+		return cards, failed
+	}
+	return cards, nil
+}
+
+// ErrUnfetchedCards is returned by FetchCards when one or more cards could
+// not be fetched. It maps each failed multiverseid to the reason it failed.
+type ErrUnfetchedCards map[int]error
+
+func (e ErrUnfetchedCards) Error() string {
+	ids := make([]int, 0, len(e))
+	for id := range e {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("failed to fetch %d card(s): ", len(ids)))
+	for i, id := range ids {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(strconv.Itoa(id) + ": " + e[id].Error())
+	}
+	return buf.String()
+}
+
+// GetCardForName searches Gatherer for the given card. If the card has more
+// than one printing, the one to return is picked as described by
+// PreferredSet. If the card was simply not found, the error is
+// ErrCardNotFound; any other error means the search itself failed, such as
+// because of a network error. CardCache is used to speed up subsequent
+// calls for the same name; the default cache is safe for concurrent use.
 func GetCardForName(name string) (Card, error) {
-	if card, ok := cardCache[name]; ok {
+	return GetCardForNameContext(context.Background(), name)
+}
+
+// GetCardForNameContext is like GetCardForName, but the search is canceled
+// if ctx is done before it completes.
+func GetCardForNameContext(ctx context.Context, name string) (Card, error) {
+	card, ok := cachedCard(name)
+	if ok {
 		return card, nil
 	}
 
-	page, err := makeGathererRequest("", name)
+	page, err := makeGathererRequest(ctx, "", name)
 	if err != nil {
 		return Card{}, err
 	}
@@ -87,7 +724,7 @@ func GetCardForName(name string) (Card, error) {
 	io.Copy(&buf, page.Body)
 	// fmt.Println(buf.String())
 
-	card, err := parseCard(&buf)
+	card, err = ParseCard(&buf)
 	if err != nil {
 		return Card{}, err
 	}
@@ -96,28 +733,95 @@ func GetCardForName(name string) (Card, error) {
 		card.MultiverseID, err = strconv.Atoi(multiverseid)
 	}
 
-	cardCache[name] = card
+	cacheCard(name, card)
 	return card, err
 }
 
-// ClearCardCache clears the internal cache used by GetCardForName.
-func ClearCardCache() {
-	cardCache = make(map[string]Card)
-	runtime.GC()
+// get performs a GET request for the given URL using HTTPClient and
+// UserAgent, waiting first for the rate limiter. Network errors and 5xx
+// responses are retried up to MaxRetries times with exponential backoff.
+// If the request fails because ctx is done, ctx.Err() is returned.
+func get(ctx context.Context, reqURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if UserAgent != "" {
+		req.Header.Set("User-Agent", UserAgent)
+	}
+	client := HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	backoff := RetryBackoff
+	for attempt := 0; ; attempt++ {
+		if err := waitForRequest(ctx); err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if ctx.Err() != nil {
+			if err == nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+		if err == nil && resp.StatusCode < 500 {
+			return resp, nil
+		}
+		if err == nil {
+			resp.Body.Close()
+			err = errors.New("unexpected response status: " + resp.Status)
+		}
+		if attempt >= MaxRetries {
+			return nil, err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// waitForRequest blocks until at least RequestInterval has passed since the
+// previous request was allowed to start, or until ctx is done.
+func waitForRequest(ctx context.Context) error {
+	nextRequestMu.Lock()
+	now := time.Now()
+	wait := nextRequest.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	nextRequest = now.Add(wait + RequestInterval)
+	nextRequestMu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func makeGathererRequest(reqURL, cardName string) (*http.Response, error) {
+func makeGathererRequest(ctx context.Context, reqURL, cardName string) (*http.Response, error) {
 	if reqURL == "" {
-		var buf bytes.Buffer
-		for _, part := range strings.Fields(cardName) {
-			buf.WriteString("+[" + part + "]")
-		}
-		query := url.Values{}
-		query.Add("name", buf.String())
-		reqURL = gathererBase + "/Pages/Search/Default.aspx?" + query.Encode()
+		reqURL = searchURL(cardName)
 	}
-	resp, err := http.Get(reqURL)
+	resp, err := get(ctx, reqURL)
 	if err != nil {
+		if err == ctx.Err() {
+			return nil, err
+		}
 		return nil, errors.New("makeGathererRequest: " + err.Error())
 	}
 	switch resp.Request.URL.Path {
@@ -125,117 +829,431 @@ func makeGathererRequest(reqURL, cardName string) (*http.Response, error) {
 		return resp, nil
 	case "/Pages/Error.aspx":
 		resp.Body.Close()
-		return nil, errors.New("makeGathererRequest: search redirected to Error.aspx")
+		return nil, ErrCardNotFound
 	case "/Pages/Search/Default.aspx":
 		doc, err := html.Parse(resp.Body)
+		resp.Body.Close()
 		if err != nil {
 			return nil, err
 		}
-		tableNode := findNode(doc, func(node *html.Node) bool {
-			return node.Type == html.ElementNode && node.Data == "table" && nodeHasClass(node, "cardItemTable")
-		})
-		if tableNode == nil {
-			return nil, errors.New("no results found; perhaps you misspelled it?")
+		results, err := parseSearchResults(doc, resp.Request.URL.Path)
+		if err != nil {
+			return nil, err
+		}
+		if len(results) == 0 {
+			return nil, ErrCardNotFound
 		}
-		cardItems := findAllNodes(tableNode, func(node *html.Node) bool {
-			return node.Type == html.ElementNode && node.Data == "tr" && nodeHasClass(node, "cardItem")
+		// Only follow an exact match, ignoring case, so that searching for
+		// "Bolt" doesn't pick up "Lightning Bolt".
+		var matches []searchResult
+		for _, result := range results {
+			if strings.EqualFold(result.Name, strings.TrimSpace(cardName)) {
+				matches = append(matches, result)
+			}
+		}
+		if len(matches) == 0 {
+			return nil, ErrCardNotFound
+		}
+		return makeGathererRequest(ctx, GathererBaseURL+choosePrinting(matches), cardName)
+	default:
+		return nil, errors.New("makeGathererRequest: unknown url path: " + resp.Request.URL.Path)
+	}
+}
+
+// searchURL returns the URL of a Gatherer search for cards whose names
+// contain every word in cardName.
+func searchURL(cardName string) string {
+	var buf bytes.Buffer
+	for _, part := range strings.Fields(cardName) {
+		// Split cards are named like "Fire // Ice", but the slashes aren't
+		// part of either half's name.
+		if part == "//" {
+			continue
+		}
+		buf.WriteString("+[" + part + "]")
+	}
+	query := url.Values{}
+	query.Add("name", buf.String())
+	return GathererBaseURL + "/Pages/Search/Default.aspx?" + query.Encode()
+}
+
+// searchResult is a card listed on a Gatherer search results page.
+type searchResult struct {
+	Name string
+	// Path is the absolute path of the card's details page.
+	Path string
+	// Printings holds every printing of the card listed alongside it,
+	// each identified by the set symbol that links to it.
+	Printings []printing
+}
+
+// printing is one printing of a card listed on a search results page.
+type printing struct {
+	SetCode string
+	// Path is the absolute path of the printing's details page.
+	Path string
+}
+
+// parseSearchResults returns the cards listed on a Gatherer search results
+// page, in the order they appear. Relative links are resolved against
+// base, the path of the results page. A page with no results table has no
+// results.
+func parseSearchResults(doc *html.Node, base string) ([]searchResult, error) {
+	tableNode := findNode(doc, func(node *html.Node) bool {
+		return node.Type == html.ElementNode && node.Data == "table" && nodeHasClass(node, "cardItemTable")
+	})
+	if tableNode == nil {
+		return nil, nil
+	}
+	cardItems := findAllNodes(tableNode, func(node *html.Node) bool {
+		return node.Type == html.ElementNode && node.Data == "tr" && nodeHasClass(node, "cardItem")
+	})
+	if len(cardItems) == 0 {
+		return nil, errors.New("no cards found in table")
+	}
+	var results []searchResult
+	for _, cardItem := range cardItems {
+		titleNode := findNode(cardItem, func(node *html.Node) bool {
+			return node.Type == html.ElementNode && node.Data == "span" && nodeHasClass(node, "cardTitle")
 		})
-		if len(cardItems) == 0 {
-			return nil, errors.New("no cards found in table")
+		if titleNode == nil {
+			continue
 		}
-		for _, cardItem := range cardItems {
-			titleNode := findNode(cardItem, func(node *html.Node) bool {
-				return node.Type == html.ElementNode && node.Data == "span" && nodeHasClass(node, "cardTitle")
+		link := titleNode.FirstChild.NextSibling
+		result := searchResult{
+			Name: strings.TrimSpace(link.FirstChild.Data),
+			Path: resolvePath(base, getAttr(link.Attr, "href")),
+		}
+		// Each printing is linked by its set symbol, whose image URL holds
+		// the set code.
+		for _, a := range findAllNodes(cardItem, func(node *html.Node) bool {
+			return node.Type == html.ElementNode && node.Data == "a"
+		}) {
+			img := findNode(a, func(node *html.Node) bool {
+				return node.Type == html.ElementNode && node.Data == "img"
 			})
-			if titleNode == nil {
+			if img == nil {
 				continue
 			}
-			if titleNode.FirstChild.NextSibling.FirstChild.Data == cardName {
-				cardUrl := getAttr(titleNode.FirstChild.NextSibling.Attr, "href")
-				return makeGathererRequest(gathererBase+resolvePath(resp.Request.URL.Path, cardUrl), cardName)
+			src, err := url.Parse(getAttr(img.Attr, "src"))
+			if err != nil || src.Query().Get("set") == "" {
+				continue
 			}
+			result.Printings = append(result.Printings, printing{
+				SetCode: src.Query().Get("set"),
+				Path:    resolvePath(base, getAttr(a.Attr, "href")),
+			})
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// choosePrinting returns the path of the details page to follow for a card
+// whose name exactly matches each of results. A printing from PreferredSet
+// is chosen if there is one; otherwise the printing with the highest
+// multiverseid is chosen, since that is usually the most recent.
+func choosePrinting(results []searchResult) string {
+	var (
+		best   string
+		bestID = -1
+	)
+	for _, result := range results {
+		printings := append([]printing{{Path: result.Path}}, result.Printings...)
+		for _, p := range printings {
+			if PreferredSet != "" && strings.EqualFold(p.SetCode, PreferredSet) {
+				return p.Path
+			}
+			if id := pathMultiverseID(p.Path); id > bestID {
+				best, bestID = p.Path, id
+			}
+		}
+	}
+	return best
+}
+
+// pathMultiverseID returns the multiverseid in the query of path, or zero
+// if it doesn't have one.
+func pathMultiverseID(path string) int {
+	u, err := url.Parse(path)
+	if err != nil {
+		return 0
+	}
+	id, _ := strconv.Atoi(u.Query().Get("multiverseid"))
+	return id
+}
+
+// SearchCards searches Gatherer for every card whose name contains all of
+// the words in name, so that "bolt" finds both "Lightning Bolt" and
+// "Bolt of Keranos". The cards are returned in the order Gatherer lists
+// them; only the first page of results is considered. Unlike
+// GetCardForName, no error is returned if nothing matches, unless Gatherer
+// redirects to its error page, in which case the error is ErrCardNotFound.
+func SearchCards(name string) ([]Card, error) {
+	return SearchCardsContext(context.Background(), name)
+}
+
+// SearchCardsContext is like SearchCards, but the search is canceled if ctx
+// is done before it completes.
+func SearchCardsContext(ctx context.Context, name string) ([]Card, error) {
+	resp, err := get(ctx, searchURL(name))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.Request.URL.Path {
+	case "/Pages/Error.aspx":
+		return nil, ErrCardNotFound
+	case "/Pages/Card/Details.aspx":
+		// Gatherer skips the results page when there's only one match.
+		card, err := ParseCard(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		card.MultiverseID, _ = strconv.Atoi(resp.Request.URL.Query().Get("multiverseid"))
+		return []Card{card}, nil
+	case "/Pages/Search/Default.aspx":
+		doc, err := html.Parse(resp.Body)
+		if err != nil {
+			return nil, err
 		}
-		return nil, errors.New("card " + cardName + " not found on search result page")
+		results, err := parseSearchResults(doc, resp.Request.URL.Path)
+		if err != nil {
+			return nil, err
+		}
+		var ids []int
+		for _, result := range results {
+			u, err := url.Parse(result.Path)
+			if err != nil {
+				continue
+			}
+			if id, err := strconv.Atoi(u.Query().Get("multiverseid")); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		fetched, err := FetchCardsContext(ctx, ids)
+		cards := make([]Card, 0, len(fetched))
+		for _, id := range ids {
+			if card, ok := fetched[id]; ok {
+				cards = append(cards, card)
+			}
+		}
+		return cards, err
 	default:
-		return nil, errors.New("makeGathererRequest: unknown url path: " + resp.Request.URL.Path)
+		return nil, errors.New("SearchCards: unknown url path: " + resp.Request.URL.Path)
 	}
 }
 
-func parseCard(r io.Reader) (Card, error) {
+// ParseCard parses a Gatherer card details page, such as one saved from a
+// previous request. FetchCard and GetCardForName use it to parse the pages
+// they download. The MultiverseID of the returned card is left zero, since
+// it only appears in the page's URL.
+func ParseCard(r io.Reader) (Card, error) {
 	doc, err := html.Parse(r)
 	if err != nil {
 		return Card{}, err
 	}
 
-	cardDetailsTable := findNode(doc, func(node *html.Node) bool {
+	tables := findAllNodes(doc, func(node *html.Node) bool {
 		return node.Type == html.ElementNode && node.Data == "table" && nodeHasClass(node, "cardDetails")
 	})
-
-	if cardDetailsTable == nil {
+	if len(tables) == 0 {
 		return Card{}, errors.New("no cardDetails table found")
 	}
 
+	// Gatherer renders one cardDetails table per face, front face first.
+	card, err := parseCardDetails(tables[0])
+	if err != nil {
+		return Card{}, err
+	}
+	if len(tables) > 1 {
+		for i := 0; i < len(tables) && i < len(card.Faces); i++ {
+			face, err := parseCardDetails(tables[i])
+			if err != nil {
+				return Card{}, err
+			}
+			card.Faces[i] = face.face()
+		}
+		// The page title of a split card names both halves, such as
+		// "Fire // Ice", while that of a double-faced card only names the
+		// front face.
+		if subtitle := findNode(doc, nodeIdHasSuffix("_subtitleDisplay")); subtitle != nil {
+			if name := strings.TrimSpace(nodeText(subtitle)); strings.Contains(name, " // ") {
+				card.combineHalves(name)
+			}
+		}
+	}
+	return card, nil
+}
+
+// combineHalves sets the fields of a split card, whose halves are already
+// in Faces, to describe the card as a whole. The mana costs of both halves
+// are concatenated, so that Colors covers both, and the converted mana cost
+// is their sum, as the rules require.
+func (c *Card) combineHalves(name string) {
+	var manaCost, texts, types []string
+	c.Name = name
+	c.ConvertedManaCost = 0
+	for _, half := range c.Faces {
+		manaCost = append(manaCost, half.ManaCost)
+		texts = append(texts, half.Text)
+		if len(types) == 0 || types[len(types)-1] != half.Type {
+			types = append(types, half.Type)
+		}
+		c.ConvertedManaCost += half.ConvertedManaCost
+	}
+	c.ManaCost = strings.Join(manaCost, "")
+	c.Text = strings.Join(texts, "\n//\n")
+	c.Type = strings.Join(types, " // ")
+}
+
+// parseCardDetails parses a single cardDetails table, which describes one
+// face of a card. Only the name row is required; any other row, or a row
+// without a value, is left out of the result.
+func parseCardDetails(cardDetailsTable *html.Node) (Card, error) {
 	var (
-		card        = Card{}
-		getRowValue = func(node *html.Node) *html.Node {
-			return findNode(node, func(node *html.Node) bool {
+		card     = Card{}
+		rowValue = func(suffix string) *html.Node {
+			row := findNode(cardDetailsTable, nodeIdHasSuffix(suffix))
+			if row == nil {
+				return nil
+			}
+			return findNode(row, func(node *html.Node) bool {
 				return nodeHasClass(node, "value")
 			})
 		}
 	)
 
 	var (
-		nameRow = findNode(cardDetailsTable, nodeIdHasSuffix("_nameRow"))
-		manaRow = findNode(cardDetailsTable, nodeIdHasSuffix("_manaRow"))
-		cmcRow  = findNode(cardDetailsTable, nodeIdHasSuffix("_cmcRow"))
-		typeRow = findNode(cardDetailsTable, nodeIdHasSuffix("_typeRow"))
-		textRow = findNode(cardDetailsTable, nodeIdHasSuffix("_textRow"))
-		// setRow       = findNode(cardDetailsTable, nodeIdHasSuffix("_setRow"))
-		// rarityRow    = findNode(cardDetailsTable, nodeIdHasSuffix("_rarityRow"))
-		// otherSetsRow = findNode(cardDetailsTable, nodeIdHasSuffix("_otherSetsRow"))
-		// numberRow    = findNode(cardDetailsTable, nodeIdHasSuffix("_numberRow"))
-		// artistRow    = findNode(cardDetailsTable, nodeIdHasSuffix("_artistRow"))
+		nameValue     = rowValue("_nameRow")
+		manaCostValue = rowValue("_manaRow")
+		cmcValue      = rowValue("_cmcRow")
+		typeValue     = rowValue("_typeRow")
+		textValue     = rowValue("_textRow")
+		flavorValue   = rowValue("_flavorRow")
+		colorValue    = rowValue("_colorIndicatorRow")
+		ptValue       = rowValue("_ptRow")
+		setValue      = rowValue("_setRow")
+		rarityValue   = rowValue("_rarityRow")
+		artistValue   = rowValue("_artistRow")
+		numberValue   = rowValue("_numberRow")
+		// otherSetsValue = rowValue("_otherSetsRow")
 	)
 
-	card.Name = strings.TrimSpace(getRowValue(nameRow).FirstChild.Data)
-	if manaRow != nil {
-		for c := getRowValue(manaRow).FirstChild.NextSibling; c != nil; c = c.NextSibling {
+	if nameValue == nil {
+		return Card{}, errors.New("cardDetails table has no name")
+	}
+	card.Name = strings.TrimSpace(nodeText(nameValue))
+	if manaCostValue != nil {
+		for c := manaCostValue.FirstChild; c != nil; c = c.NextSibling {
 			part := getAttr(c.Attr, "alt")
-			if _, err := strconv.Atoi(part); err == nil {
-				card.ManaCost += part
+			if part == "" {
+				continue
+			}
+			if symbol, ok := manaSymbol(part); ok {
+				card.ManaCost += "{" + symbol + "}"
 			} else {
-				switch strings.ToUpper(part) {
-				case "WHITE":
-					card.ManaCost += "W"
-				case "BLUE":
-					card.ManaCost += "U"
-				case "BLACK":
-					card.ManaCost += "B"
-				case "RED":
-					card.ManaCost += "R"
-				case "GREEN":
-					card.ManaCost += "G"
-				default:
-					fmt.Println("unknown mana cost part: " + part)
-				}
+				fmt.Println("unknown mana cost part: " + part)
 			}
 		}
 	}
-	if cmcRow != nil {
-		if cmc, err := strconv.Atoi(getRowValue(cmcRow).FirstChild.Data); err == nil {
+	if cmcValue != nil {
+		if cmc, err := strconv.Atoi(strings.TrimSpace(nodeText(cmcValue))); err == nil {
 			card.ConvertedManaCost = cmc
 		}
+	} else {
+		card.ConvertedManaCost = manaValue(manaSymbols(card.ManaCost))
+	}
+	if typeValue != nil {
+		card.Type = strings.TrimSpace(nodeText(typeValue))
+	}
+	if textValue != nil {
+		card.Text = cardText(textValue)
+	}
+	if colorValue != nil {
+		for _, name := range strings.Split(nodeText(colorValue), ",") {
+			if symbol, ok := manaSymbol(strings.TrimSpace(name)); ok {
+				card.ColorIndicator += symbol
+			}
+		}
+	}
+	if flavorValue != nil {
+		var paragraphs []string
+		for _, box := range findAllNodes(flavorValue, func(node *html.Node) bool {
+			return node.Type == html.ElementNode && nodeHasClass(node, "flavortextbox")
+		}) {
+			paragraphs = append(paragraphs, strings.TrimSpace(nodeText(box)))
+		}
+		card.FlavorText = strings.Join(paragraphs, "\n")
+	}
+	if ptValue != nil {
+		// Gatherer uses the same row for a creature's power and toughness
+		// and a planeswalker's starting loyalty.
+		value := strings.TrimSpace(nodeText(ptValue))
+		if strings.Contains(card.Type, "Planeswalker") {
+			if loyalty, err := strconv.Atoi(value); err == nil {
+				card.Loyalty = loyalty
+			}
+		} else if parts := strings.SplitN(value, "/", 2); len(parts) == 2 {
+			card.Power = strings.TrimSpace(parts[0])
+			card.Toughness = strings.TrimSpace(parts[1])
+		}
+	}
+	if setValue != nil {
+		card.Set = strings.TrimSpace(nodeText(setValue))
+		// The set code is only available in the URL of the set symbol.
+		symbol := findNode(setValue, func(node *html.Node) bool {
+			return node.Type == html.ElementNode && node.Data == "img"
+		})
+		if symbol != nil {
+			if src, err := url.Parse(getAttr(symbol.Attr, "src")); err == nil {
+				card.SetCode = src.Query().Get("set")
+			}
+		}
 	}
-	if typeRow != nil {
-		card.Type = strings.TrimSpace(getRowValue(typeRow).FirstChild.Data)
+	if rarityValue != nil {
+		card.Rarity = parseRarity(rarityValue)
 	}
-	if textRow != nil {
-		card.Text = strings.TrimSpace(getRowValue(textRow).FirstChild.NextSibling.FirstChild.Data)
+	if numberValue != nil {
+		card.Number = strings.TrimSpace(nodeText(numberValue))
+	}
+	if artistValue != nil {
+		var artists []string
+		for _, link := range findAllNodes(artistValue, func(node *html.Node) bool {
+			return node.Type == html.ElementNode && node.Data == "a"
+		}) {
+			artists = append(artists, strings.TrimSpace(nodeText(link)))
+		}
+		if len(artists) == 0 {
+			artists = append(artists, strings.TrimSpace(nodeText(artistValue)))
+		}
+		card.Artist = strings.Join(artists, ", ")
 	}
 
 	return card, nil
 }
 
+// parseRarity extracts the rarity name from the value node of a rarity row.
+// Gatherer usually renders it as the text of a span, but older pages use an
+// image whose alt text holds the rarity, sometimes as "Set Name (Rarity)".
+func parseRarity(value *html.Node) string {
+	if rarity := strings.TrimSpace(nodeText(value)); rarity != "" {
+		return rarity
+	}
+	img := findNode(value, func(node *html.Node) bool {
+		return node.Type == html.ElementNode && node.Data == "img"
+	})
+	if img == nil {
+		return ""
+	}
+	alt := strings.TrimSpace(getAttr(img.Attr, "alt"))
+	if i, j := strings.LastIndex(alt, "("), strings.LastIndex(alt, ")"); i != -1 && j > i {
+		alt = alt[i+1 : j]
+	}
+	return strings.TrimSpace(alt)
+}
+
 func nodeSearch(root *html.Node, f func(*html.Node) bool, stopAtOne bool) (nodes []*html.Node) {
 	queue := list.New()
 	queue.PushBack(root)
@@ -281,6 +1299,83 @@ func nodeIdHasSuffix(suffix string) func(*html.Node) bool {
 	}
 }
 
+// cardText renders the value node of a card text row. Each paragraph is
+// placed on its own line, and mana symbol images are replaced with their
+// bracketed symbols.
+func cardText(value *html.Node) string {
+	boxes := findAllNodes(value, func(node *html.Node) bool {
+		return node.Type == html.ElementNode && nodeHasClass(node, "cardtextbox")
+	})
+	if len(boxes) == 0 {
+		return strings.TrimSpace(symbolText(value))
+	}
+	paragraphs := make([]string, len(boxes))
+	for i, box := range boxes {
+		paragraphs[i] = strings.TrimSpace(symbolText(box))
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+// symbolText is like nodeText, but also renders symbol images as their
+// bracketed symbols.
+func symbolText(node *html.Node) string {
+	var (
+		buf bytes.Buffer
+		f   func(*html.Node)
+	)
+	f = func(node *html.Node) {
+		switch {
+		case node.Type == html.TextNode:
+			buf.WriteString(node.Data)
+		case node.Type == html.ElementNode && node.Data == "img":
+			if alt := strings.TrimSpace(getAttr(node.Attr, "alt")); alt != "" {
+				buf.WriteString("{" + textSymbol(alt) + "}")
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			f(child)
+		}
+	}
+	f(node)
+	return buf.String()
+}
+
+// textSymbol converts the alt text of a symbol image in rules text into
+// the text of that symbol, without braces. Besides mana symbols, this
+// includes the tap and untap symbols. Unknown symbols keep their alt text,
+// so that they at least aren't lost.
+func textSymbol(alt string) string {
+	switch strings.ToUpper(alt) {
+	case "TAP":
+		return "T"
+	case "UNTAP":
+		return "Q"
+	}
+	if symbol, ok := manaSymbol(alt); ok {
+		return symbol
+	}
+	return alt
+}
+
+// nodeText returns the text content of node and all of its descendants, in
+// document order.
+func nodeText(node *html.Node) string {
+	var (
+		buf bytes.Buffer
+		f   func(*html.Node)
+	)
+	f = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			buf.WriteString(node.Data)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			f(child)
+		}
+	}
+	f(node)
+	return buf.String()
+}
+
 func getAttr(attrs []html.Attribute, name string) string {
 	for _, attr := range attrs {
 		if attr.Key == name {
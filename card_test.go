@@ -0,0 +1,289 @@
+package mtg
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// roundTripFunc adapts a function into an http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// useTransport makes every request go through rt, without waiting between
+// requests or before retries, until the returned function is called.
+func useTransport(rt http.RoundTripper) (restore func()) {
+	oldClient, oldInterval, oldBackoff := HTTPClient, RequestInterval, RetryBackoff
+	HTTPClient = &http.Client{Transport: rt}
+	RequestInterval, RetryBackoff = 0, 0
+	return func() {
+		HTTPClient, RequestInterval, RetryBackoff = oldClient, oldInterval, oldBackoff
+	}
+}
+
+func response(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestGetRetriesServerErrors(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+	)
+	defer useTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts <= 2 {
+			return response(req, http.StatusInternalServerError, ""), nil
+		}
+		return response(req, http.StatusOK, "ok"), nil
+	}))()
+
+	resp, err := get(context.Background(), GathererBaseURL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if attempts != 3 {
+		t.Errorf("made %d attempts, want 3", attempts)
+	}
+}
+
+func TestGetGivesUpAfterMaxRetries(t *testing.T) {
+	var attempts int
+	defer useTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return response(req, http.StatusServiceUnavailable, ""), nil
+	}))()
+
+	if _, err := get(context.Background(), GathererBaseURL+"/"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if want := MaxRetries + 1; attempts != want {
+		t.Errorf("made %d attempts, want %d", attempts, want)
+	}
+}
+
+func TestErrorPageIsNotRetried(t *testing.T) {
+	var attempts int
+	defer useTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if req.URL.Path == "/Pages/Error.aspx" {
+			return response(req, http.StatusOK, "<html></html>"), nil
+		}
+		resp := response(req, http.StatusFound, "")
+		resp.Header.Set("Location", "/Pages/Error.aspx")
+		return resp, nil
+	}))()
+
+	_, err := makeGathererRequest(context.Background(), "", "Lightning Bolt")
+	if err != ErrCardNotFound {
+		t.Errorf("got error %v, want ErrCardNotFound", err)
+	}
+	// One request for the search, and one to follow the redirect.
+	if attempts != 2 {
+		t.Errorf("made %d attempts, want 2", attempts)
+	}
+}
+
+func TestFetchCardErrorPage(t *testing.T) {
+	defer useTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/Pages/Error.aspx" {
+			return response(req, http.StatusOK, "<html></html>"), nil
+		}
+		resp := response(req, http.StatusFound, "")
+		resp.Header.Set("Location", "/Pages/Error.aspx")
+		return resp, nil
+	}))()
+
+	if _, err := FetchCardContext(context.Background(), 1); err != ErrCardNotFound {
+		t.Errorf("got error %v, want ErrCardNotFound", err)
+	}
+}
+
+func TestParseCardMalformed(t *testing.T) {
+	for name, page := range map[string]string{
+		"no name row":   `<table class="cardDetails"><tr id="x_typeRow"><td><div class="value">Instant</div></td></tr></table>`,
+		"no name value": `<table class="cardDetails"><tr id="x_nameRow"><td></td></tr></table>`,
+	} {
+		if _, err := ParseCard(strings.NewReader(page)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// Rows other than the name may lack a value.
+	page := `<table class="cardDetails">
+		<tr id="x_nameRow"><td><div class="value">Shock</div></td></tr>
+		<tr id="x_manaRow"><td></td></tr>
+		<tr id="x_cmcRow"><td></td></tr>
+		<tr id="x_ptRow"><td></td></tr>
+	</table>`
+	card, err := ParseCard(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if card.Name != "Shock" {
+		t.Errorf("got name %q, want %q", card.Name, "Shock")
+	}
+}
+
+func TestParseCard(t *testing.T) {
+	tests := []struct {
+		file string
+		want Card
+	}{
+		{
+			file: "creature.html",
+			want: Card{
+				Name:              "Llanowar Elves",
+				ManaCost:          "{G}",
+				ConvertedManaCost: 1,
+				Type:              "Creature — Elf Druid",
+				Text:              "{T}: Add {G}.",
+				FlavorText:        "The elves of the Llanowar forest have defended it for generations.",
+				Power:             "1",
+				Toughness:         "1",
+				Set:               "Dominaria",
+				SetCode:           "DOM",
+				Number:            "168",
+				Rarity:            "Common",
+				Artist:            "Chris Rahn",
+			},
+		},
+		{
+			file: "planeswalker.html",
+			want: Card{
+				Name:              "Liliana of the Veil",
+				ManaCost:          "{1}{B}{B}",
+				ConvertedManaCost: 3,
+				Type:              "Legendary Planeswalker — Liliana",
+				Text: "+1: Each player discards a card.\n" +
+					"−2: Target player sacrifices a creature.\n" +
+					"−6: Separate all permanents target player controls into two piles. " +
+					"That player sacrifices all permanents in the pile of their choice.",
+				Loyalty: 3,
+				Set:     "Innistrad",
+				SetCode: "ISD",
+				Number:  "105",
+				Rarity:  "Mythic Rare",
+				Artist:  "Steve Argyle",
+			},
+		},
+		{
+			file: "split.html",
+			want: Card{
+				Name:              "Fire // Ice",
+				ManaCost:          "{1}{R}{1}{U}",
+				ConvertedManaCost: 4,
+				Type:              "Instant",
+				Text: "Fire deals 2 damage divided as you choose among one or two targets.\n" +
+					"//\n" +
+					"Tap target permanent.\nDraw a card.",
+				Set:     "Apocalypse",
+				SetCode: "AP",
+				Number:  "128",
+				Rarity:  "Uncommon",
+				Artist:  "Franz Vohwinkel",
+				Faces: [2]CardFace{
+					{
+						Name:              "Fire",
+						ManaCost:          "{1}{R}",
+						ConvertedManaCost: 2,
+						Type:              "Instant",
+						Text:              "Fire deals 2 damage divided as you choose among one or two targets.",
+						Artist:            "Franz Vohwinkel",
+					},
+					{
+						Name:              "Ice",
+						ManaCost:          "{1}{U}",
+						ConvertedManaCost: 2,
+						Type:              "Instant",
+						Text:              "Tap target permanent.\nDraw a card.",
+						Artist:            "Franz Vohwinkel",
+					},
+				},
+			},
+		},
+		{
+			file: "land.html",
+			want: Card{
+				Name:    "Forest",
+				Type:    "Basic Land — Forest",
+				Text:    "({T}: Add {G}.)",
+				Set:     "Dominaria",
+				SetCode: "DOM",
+				Number:  "266",
+				Rarity:  "Land",
+				Artist:  "John Avon",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		f, err := os.Open(filepath.Join("testdata", test.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		card, err := ParseCard(f)
+		f.Close()
+		if err != nil {
+			t.Errorf("%s: %v", test.file, err)
+			continue
+		}
+		if card != test.want {
+			t.Errorf("%s: got\n%+v\nwant\n%+v", test.file, card, test.want)
+		}
+	}
+}
+
+func TestMultiFacedTypes(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "split.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fireIce, err := ParseCard(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		card     Card
+		types    []string
+		subtypes []string
+	}{
+		{fireIce, []string{"Instant"}, nil},
+		{Card{Type: "Instant // Sorcery"}, []string{"Instant", "Sorcery"}, nil},
+		{
+			Card{Type: "Creature — Giant // Instant — Adventure"},
+			[]string{"Creature", "Instant"},
+			[]string{"Giant", "Adventure"},
+		},
+	}
+	for _, test := range tests {
+		if types := test.card.Types(); strings.Join(types, ",") != strings.Join(test.types, ",") {
+			t.Errorf("%q: got types %q, want %q", test.card.Type, types, test.types)
+		}
+		if subtypes := test.card.Subtypes(); strings.Join(subtypes, ",") != strings.Join(test.subtypes, ",") {
+			t.Errorf("%q: got subtypes %q, want %q", test.card.Type, subtypes, test.subtypes)
+		}
+	}
+}
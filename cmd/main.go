@@ -1,20 +1,20 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"os"
 
 	"github.com/dradtke/mtg"
 )
 
 func main() {
-	f, err := os.Open("../testdata/hou.dec")
-	if err != nil {
-		panic(err)
-	}
-
-	deck, err := mtg.NewDeck(f)
-	if err != nil {
+	var unresolved mtg.ErrUnresolvedCards
+	deck, err := mtg.NewDeckFromFile("../testdata/hou.dec")
+	if errors.As(err, &unresolved) {
+		for name, err := range unresolved {
+			fmt.Println("failed to find card " + name + ": " + err.Error())
+		}
+	} else if err != nil {
 		panic(err)
 	}
 
@@ -0,0 +1,40 @@
+package mtg
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// WriteCSV writes the deck to w as CSV, with a header row followed by one
+// row per unique card. The section column is either "main" or "sideboard".
+func (d Deck) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"count", "name", "mana cost", "cmc", "type", "rarity", "section"}); err != nil {
+		return err
+	}
+	for _, section := range []struct {
+		name    string
+		entries []DeckEntry
+	}{
+		{"main", d.MainEntries()},
+		{"sideboard", d.SideboardEntries()},
+	} {
+		for _, entry := range section.entries {
+			record := []string{
+				strconv.Itoa(entry.Count),
+				entry.Card.Name,
+				entry.Card.ManaCost,
+				strconv.Itoa(entry.Card.ConvertedManaCost),
+				entry.Card.Type,
+				entry.Card.Rarity,
+				section.name,
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
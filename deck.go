@@ -3,41 +3,175 @@ package mtg
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 )
 
 var (
-	decLineRe = regexp.MustCompile(`^(\d+) (.+)$`)
+	// decLineRe matches a card line such as "4 Lightning Bolt". The count
+	// may be followed by an "x", as in "4x Lightning Bolt", or left out
+	// entirely to mean a single copy, and may be separated from the name by
+	// spaces or tabs.
+	decLineRe = regexp.MustCompile(`^(?:(\d+)[xX]?[ \t]+)?(\S.*)$`)
 
 	ErrDeckTooSmall = errors.New("deck is too small")
+	ErrDeckTooLarge = errors.New("deck is too large")
+	ErrNoCommander  = errors.New("deck has no commander")
+	ErrInvalidCount = errors.New("count must be positive")
+
+	// ErrNoCardsResolved is reported when a decklist names at least one
+	// card but none of them could be resolved, which usually means the
+	// card provider is unreachable. Check for it with errors.Is; the error
+	// still unwraps to ErrUnresolvedCards with the reason for each card.
+	ErrNoCardsResolved = errors.New("none of the cards in the deck could be resolved")
+
+	// CommentPrefixes holds the prefixes that mark a line of a .dec file as
+	// a comment, such as "// Creatures". Comment lines are skipped.
+	CommentPrefixes = []string{"//", "#"}
+
+	// MaxConcurrentLookups is the maximum number of card lookups NewDeck
+	// and FetchCards will have in flight at once. Values less than 1 are
+	// treated as 1.
+	MaxConcurrentLookups = 4
 )
 
 // A deck represents your Magic deck. The Main field maps from card name
 // to how many of them are in the deck, and Sideboard does the same for
-// cards in your sideboard.
+// cards in your sideboard. Commander is the deck's commander, for formats
+// that have one; it may or may not also appear in Main. Companion is the
+// deck's companion, if it has one, which must also be in the sideboard.
 type Deck struct {
 	Main      map[Card]int
 	Sideboard map[Card]int
+	Commander *Card
+	Companion *Card
+
+	// mainOrder and sideboardOrder record the order in which cards were
+	// added to Main and Sideboard, so that output can follow the order of
+	// the original decklist.
+	mainOrder, sideboardOrder []Card
+}
+
+// DeckEntry is a card in a deck along with its number of copies.
+type DeckEntry struct {
+	Card  Card `json:"card"`
+	Count int  `json:"count"`
 }
 
 // NewDeck creates a new deck from the provided reader, which should provide
-// deck information in .dec format.
+// deck information in .dec format. Cards are looked up using DefaultProvider.
+// If any of the cards can't be found, the error will be of type
+// ErrUnresolvedCards, and if none of them can be, it will also match
+// ErrNoCardsResolved. An empty decklist gives an empty deck and no error.
 func NewDeck(r io.Reader) (Deck, error) {
-	main, sideboard := make(map[string]int), make(map[string]int)
+	return NewDeckContext(context.Background(), r)
+}
+
+// NewDeckFromFile creates a new deck from the .dec file at path. Errors
+// from NewDeck are wrapped with the path, so use errors.As to check for
+// ErrUnresolvedCards.
+func NewDeckFromFile(path string) (Deck, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Deck{}, err
+	}
+	defer f.Close()
+
+	deck, err := NewDeck(f)
+	if err != nil {
+		return deck, fmt.Errorf("%s: %w", path, err)
+	}
+	return deck, nil
+}
+
+// NewDeckContext is like NewDeck, but card lookups are canceled if ctx is
+// done before they complete, in which case ctx.Err() is returned.
+func NewDeckContext(ctx context.Context, r io.Reader) (Deck, error) {
+	return NewDeckFromProvider(ctx, r, DefaultProvider)
+}
+
+// NewDeckFromProvider is like NewDeckContext, but looks up cards using the
+// given provider instead of DefaultProvider.
+func NewDeckFromProvider(ctx context.Context, r io.Reader, p CardProvider) (Deck, error) {
+	return NewDeckWithProgress(ctx, r, p, nil)
+}
+
+// NewDeckWithProgress is like NewDeckFromProvider, but calls progress each
+// time a card lookup finishes, whether or not it succeeded, with the number
+// of lookups done so far and the total number of unique cards to look up.
+// Calls to progress are never made concurrently, but they may come from
+// different goroutines, so progress should return quickly. It may be nil.
+func NewDeckWithProgress(ctx context.Context, r io.Reader, p CardProvider, progress func(done, total int)) (Deck, error) {
+	list, err := parseDec(r)
+	if err != nil {
+		return Deck{}, err
+	}
+	return list.resolve(ctx, p, progress)
+}
+
+// deckList holds the card names read from a decklist, before they are
+// resolved into Cards. The order slices record the order in which each name
+// first appeared. The commander and companion are empty if the decklist
+// doesn't name one.
+type deckList struct {
+	main, sideboard           map[string]int
+	mainOrder, sideboardOrder []string
+	commander, companion      string
+}
+
+func newDeckList() *deckList {
+	return &deckList{main: make(map[string]int), sideboard: make(map[string]int)}
+}
+
+func (l *deckList) add(cardName string, count int, isSideboard bool) {
+	counts, order := l.main, &l.mainOrder
+	if isSideboard {
+		counts, order = l.sideboard, &l.sideboardOrder
+	}
+	if _, ok := counts[cardName]; !ok {
+		*order = append(*order, cardName)
+	}
+	counts[cardName] += count
+}
+
+// parseDec reads a decklist in .dec format. Besides the usual "SB:" prefix
+// for sideboard cards, a line starting with "COMMANDER:" names the deck's
+// commander, and one starting with "COMPANION:" names its companion, which
+// is added to the sideboard if it isn't listed there too.
+func parseDec(r io.Reader) (*deckList, error) {
+	list := newDeckList()
 
 	scanner := bufio.NewScanner(r)
-	for scanner.Scan() {
+	for lineNum := 1; scanner.Scan(); lineNum++ {
 		var (
 			line        = strings.TrimSpace(scanner.Text())
 			isSideboard bool
 		)
-		if line == "" {
+		if line == "" || isComment(line) {
+			continue
+		}
+		if rest, ok := cutPrefixFold(line, "COMMANDER:"); ok {
+			_, cardName, err := parseCardLine(rest)
+			if err != nil {
+				return nil, ParseError{lineNum, scanner.Text()}
+			}
+			list.commander = cardName
+			continue
+		}
+		if rest, ok := cutPrefixFold(line, "COMPANION:"); ok {
+			_, cardName, err := parseCardLine(rest)
+			if err != nil {
+				return nil, ParseError{lineNum, scanner.Text()}
+			}
+			list.companion = cardName
 			continue
 		}
 		if len(line) > 3 && line[:3] == "SB:" {
@@ -47,59 +181,181 @@ func NewDeck(r io.Reader) (Deck, error) {
 
 		count, cardName, err := parseCardLine(line)
 		if err != nil {
-			return Deck{}, err
+			return nil, ParseError{lineNum, scanner.Text()}
 		}
 
-		if isSideboard {
-			sideboard[cardName] += count
-		} else {
-			main[cardName] += count
-		}
+		list.add(cardName, count, isSideboard)
+	}
+
+	return list, scanner.Err()
+}
 
+// cutPrefixFold returns s without prefix, trimmed of spaces, and true if s
+// starts with prefix, ignoring case.
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
 	}
+	return strings.TrimSpace(s[len(prefix):]), true
+}
 
-	var (
-		mu   sync.Mutex
-		wg   sync.WaitGroup
-		deck = Deck{
-			Main:      make(map[Card]int),
-			Sideboard: make(map[Card]int),
+// isComment reports whether line starts with one of CommentPrefixes.
+func isComment(line string) bool {
+	for _, prefix := range CommentPrefixes {
+		if prefix != "" && strings.HasPrefix(line, prefix) {
+			return true
 		}
+	}
+	return false
+}
+
+// resolve looks up every card name in the list using p and builds a Deck
+// from the results. If any of the names can't be found, the error will be
+// of type ErrUnresolvedCards. If progress isn't nil, it is called as
+// described by NewDeckWithProgress.
+func (l *deckList) resolve(ctx context.Context, p CardProvider, progress func(done, total int)) (Deck, error) {
+	var (
+		mu         sync.Mutex
+		wg         sync.WaitGroup
+		cards      = make(map[string]Card)
+		unresolved = make(ErrUnresolvedCards)
+		done       int
+		total      int
 	)
 
-	wg.Add(len(main) + len(sideboard))
-	for cardName, count := range main {
-		go func(cardName string, count int) {
-			defer wg.Done()
-			card, err := GetCardForName(cardName)
-			if err != nil {
-				fmt.Println("failed to find card " + cardName + ": " + err.Error())
-				return
-			}
+	limit := MaxConcurrentLookups
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
 
-			mu.Lock()
-			deck.Main[card] += count
-			mu.Unlock()
-		}(cardName, count)
+	resolve := func(cardName string) {
+		defer wg.Done()
+		sem <- struct{}{}
+		card, err := p.GetCardForName(ctx, cardName)
+		<-sem
+		if err == nil && card.Name == "" {
+			err = ErrCardNotFound
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			unresolved[cardName] = err
+		} else {
+			cards[cardName] = card
+		}
+		done++
+		if progress != nil {
+			progress(done, total)
+		}
 	}
 
-	for cardName, count := range sideboard {
-		go func(cardName string, count int) {
-			defer wg.Done()
-			card, err := GetCardForName(cardName)
-			if err != nil {
-				fmt.Println("failed to find card " + cardName + ": " + err.Error())
-				return
+	names := make(map[string]struct{}, len(l.main)+len(l.sideboard))
+	for _, cardNames := range [][]string{l.mainOrder, l.sideboardOrder, {l.commander, l.companion}} {
+		for _, cardName := range cardNames {
+			if cardName != "" {
+				names[cardName] = struct{}{}
 			}
+		}
+	}
+	total = len(names)
+	wg.Add(total)
+	for cardName := range names {
+		go resolve(cardName)
+	}
+	wg.Wait()
 
-			mu.Lock()
-			deck.Sideboard[card] += count
-			mu.Unlock()
-		}(cardName, count)
+	if err := ctx.Err(); err != nil {
+		return Deck{}, err
 	}
 
-	wg.Wait()
-	return deck, scanner.Err()
+	deck := Deck{
+		Main:      make(map[Card]int),
+		Sideboard: make(map[Card]int),
+	}
+	for _, cardName := range l.mainOrder {
+		if card, ok := cards[cardName]; ok {
+			deck.add(card, l.main[cardName], false)
+		}
+	}
+	for _, cardName := range l.sideboardOrder {
+		if card, ok := cards[cardName]; ok {
+			deck.add(card, l.sideboard[cardName], true)
+		}
+	}
+	if card, ok := cards[l.commander]; ok {
+		deck.Commander = &card
+	}
+	if card, ok := cards[l.companion]; ok {
+		deck.Companion = &card
+		if _, ok := l.sideboard[l.companion]; !ok {
+			deck.add(card, 1, true)
+		}
+	}
+
+	if len(unresolved) > 0 && len(cards) == 0 {
+		return deck, noCardsResolved{unresolved}
+	}
+	if len(unresolved) > 0 {
+		return deck, unresolved
+	}
+	return deck, nil
+}
+
+// noCardsResolved is the error returned by resolve when every card failed.
+// It matches ErrNoCardsResolved and unwraps to the per-card errors.
+type noCardsResolved struct {
+	unresolved ErrUnresolvedCards
+}
+
+func (e noCardsResolved) Error() string {
+	return ErrNoCardsResolved.Error() + ": " + e.unresolved.Error()
+}
+
+func (e noCardsResolved) Is(target error) bool {
+	return target == ErrNoCardsResolved
+}
+
+func (e noCardsResolved) Unwrap() error {
+	return e.unresolved
+}
+
+// ParseError is returned when a line of a decklist can't be parsed. Line is
+// the line number, starting at 1, and Text is the line itself.
+type ParseError struct {
+	Line int
+	Text string
+}
+
+func (e ParseError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("line '%s' is not a valid card definition", e.Text)
+	}
+	return fmt.Sprintf("line %d: '%s' is not a valid card definition", e.Line, e.Text)
+}
+
+// ErrUnresolvedCards is returned by NewDeck when one or more card names
+// could not be resolved. It maps each failed name to the reason it failed.
+// The deck returned alongside it still contains every card that was found.
+type ErrUnresolvedCards map[string]error
+
+func (e ErrUnresolvedCards) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("failed to resolve %d card(s): ", len(names)))
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(name + ": " + e[name].Error())
+	}
+	return buf.String()
 }
 
 func (d Deck) Colors() []string {
@@ -119,6 +375,93 @@ func (d Deck) Colors() []string {
 	return colors
 }
 
+// ColorDistribution returns the number of colored mana symbols of each
+// color in the mana costs of the nonland cards in the main deck, counting
+// every copy, so a card costing {1}{G}{G} adds 2 to "G". A hybrid symbol
+// such as {G/W} counts once toward each of its colors.
+func (d Deck) ColorDistribution() map[string]int {
+	distribution := make(map[string]int)
+	for card, count := range d.Main {
+		if card.IsLand() {
+			continue
+		}
+		for _, symbol := range manaSymbols(card.ManaCost) {
+			for _, color := range symbolColors([]string{symbol}) {
+				distribution[color] += count
+			}
+		}
+	}
+	return distribution
+}
+
+// addManaRe matches the mana produced by a mana ability in rules text, such
+// as "{W} or {U}" in "{T}: Add {W} or {U}.".
+var addManaRe = regexp.MustCompile(`Add ([^.]*)`)
+
+// basicLandColors maps each basic land type to the color it taps for.
+var basicLandColors = map[string]string{
+	"Plains":   "W",
+	"Island":   "U",
+	"Swamp":    "B",
+	"Mountain": "R",
+	"Forest":   "G",
+}
+
+// ManaSources returns, for each color, the number of cards in the main deck
+// that can produce mana of that color, counting every copy. A card that can
+// produce more than one color, such as a dual land, counts toward each of
+// them. Both lands and nonland cards such as mana creatures are counted,
+// and cards that produce colorless mana count toward "C".
+//
+// The colors are found from basic land types and by scanning the rules
+// text for "Add" abilities, so the result is only an approximation; for
+// example, a card that adds mana of any color counts toward all five.
+func (d Deck) ManaSources() map[string]int {
+	sources := make(map[string]int)
+	for card, count := range d.Main {
+		for _, color := range manaProduced(card) {
+			sources[color] += count
+		}
+	}
+	return sources
+}
+
+// manaProduced returns the colors of mana card can produce, in WUBRG order,
+// followed by "C" if it can produce colorless mana.
+func manaProduced(card Card) []string {
+	var symbols []string
+	for _, subtype := range card.Subtypes() {
+		if color, ok := basicLandColors[subtype]; ok {
+			symbols = append(symbols, color)
+		}
+	}
+	for _, match := range addManaRe.FindAllStringSubmatch(card.Text, -1) {
+		if strings.Contains(match[1], "any color") {
+			symbols = append(symbols, allColors...)
+		}
+		symbols = append(symbols, manaSymbols(match[1])...)
+	}
+	colors := symbolColors(symbols)
+	for _, symbol := range symbols {
+		if symbol == colorless {
+			return append(colors, colorless)
+		}
+	}
+	return colors
+}
+
+// ColorsWithColorless is like Colors, but also includes "C" at the end if
+// the main deck contains any colorless cards, including lands.
+func (d Deck) ColorsWithColorless() []string {
+	colors := d.Colors()
+	for card := range d.Main {
+		if len(card.Colors()) == 0 {
+			return append(colors, colorless)
+		}
+	}
+	return colors
+}
+
 func (d Deck) Size() (size int) {
 	for _, n := range d.Main {
 		size += n
@@ -126,84 +469,557 @@ func (d Deck) Size() (size int) {
 	return
 }
 
+// SideboardSize returns the number of cards in the sideboard, counting
+// every copy.
+func (d Deck) SideboardSize() int {
+	return countCards(d.Sideboard)
+}
+
 func (d Deck) Lands() (map[Card]int, int) {
+	return d.filterMain(Card.IsLand)
+}
+
+// Creatures returns the creature cards in the main deck, including artifact
+// creatures and creature lands, along with the total number of them
+// counting every copy.
+func (d Deck) Creatures() (map[Card]int, int) {
+	return d.filterMain(Card.IsCreature)
+}
+
+// Spells returns the cards in the main deck that are neither lands nor
+// creatures, along with the total number of them counting every copy. It
+// follows the usual split of a decklist into lands, creatures, and spells,
+// so unlike Card.IsSpell, it includes noncreature permanents such as
+// artifacts and enchantments.
+func (d Deck) Spells() (map[Card]int, int) {
+	return d.filterMain(func(card Card) bool {
+		return !card.IsLand() && !card.IsCreature()
+	})
+}
+
+// CardsWithCMC returns the nonland cards in the main deck with the given
+// converted mana cost, along with how many copies of each there are.
+func (d Deck) CardsWithCMC(cmc int) map[Card]int {
+	return d.CardsInCMCRange(cmc, cmc)
+}
+
+// CardsInCMCRange returns the nonland cards in the main deck whose
+// converted mana cost is between lo and hi inclusive, along with how many
+// copies of each there are.
+func (d Deck) CardsInCMCRange(lo, hi int) map[Card]int {
+	cards, _ := d.filterMain(func(card Card) bool {
+		return !card.IsLand() && card.ConvertedManaCost >= lo && card.ConvertedManaCost <= hi
+	})
+	return cards
+}
+
+// filterMain returns the cards in the main deck for which keep returns
+// true, along with the total number of them counting every copy.
+func (d Deck) filterMain(keep func(Card) bool) (map[Card]int, int) {
 	var (
-		lands = make(map[Card]int)
+		cards = make(map[Card]int)
 		total int
 	)
 	for card, count := range d.Main {
-		if card.Type == "Land" || card.Type == "Basic Land" || strings.HasPrefix(card.Type, "Land ") || strings.HasPrefix(card.Type, "Basic Land ") {
-			lands[card] = count
+		if keep(card) {
+			cards[card] = count
 			total += count
 		}
 	}
-	return lands, total
+	return cards, total
 }
 
-type ErrCardLimitExceeded struct {
-	Card string
+// NonlandCount returns the number of nonland cards in the main deck,
+// counting every copy.
+func (d Deck) NonlandCount() int {
+	_, lands := d.Lands()
+	return d.Size() - lands
 }
 
-func (e ErrCardLimitExceeded) Error() string {
-	return "too many copies of: " + e.Card
-}
+// breakdownTypes are the card types counted by TypeBreakdown.
+var breakdownTypes = []string{"Creature", "Instant", "Sorcery", "Artifact", "Enchantment", "Planeswalker"}
 
-type Format int
-
-const (
-	_ Format = iota
-	Constructed
-	Limited
-)
+// TypeBreakdown returns the number of cards in the main deck of each major
+// card type: Creature, Instant, Sorcery, Artifact, Enchantment,
+// Planeswalker, and Land, counting every copy. A card with more than one
+// type counts toward each of them, so an artifact creature is counted as
+// both an Artifact and a Creature, and the totals may add up to more than
+// the size of the deck.
+func (d Deck) TypeBreakdown() map[string]int {
+	breakdown := make(map[string]int)
+	for card, count := range d.Main {
+		for _, t := range breakdownTypes {
+			if card.hasType(t) {
+				breakdown[t] += count
+			}
+		}
+		if card.IsLand() {
+			breakdown["Land"] += count
+		}
+	}
+	return breakdown
+}
 
-func (d Deck) Validate(format Format) error {
-	switch format {
-	case Constructed:
-		if d.Size() < 60 {
-			return ErrDeckTooSmall
+// RarityBreakdown returns the number of cards in the main deck of each
+// rarity, counting every copy. Cards whose rarity isn't known are counted
+// under "Unknown".
+func (d Deck) RarityBreakdown() map[string]int {
+	breakdown := make(map[string]int)
+	for card, count := range d.Main {
+		rarity := card.Rarity
+		if rarity == "" {
+			rarity = "Unknown"
 		}
+		breakdown[rarity] += count
+	}
+	return breakdown
+}
+
+// KeywordCounts returns, for each of the given keywords, the number of
+// cards in the main deck that have it according to Card.HasKeyword,
+// counting every copy.
+func (d Deck) KeywordCounts(keywords []string) map[string]int {
+	counts := make(map[string]int, len(keywords))
+	for _, kw := range keywords {
+		counts[kw] = 0
 		for card, count := range d.Main {
-			// TODO: add check for basic land
-			if count > 4 {
-				return ErrCardLimitExceeded{card.Name}
+			if card.HasKeyword(kw) {
+				counts[kw] += count
 			}
 		}
-		return nil
+	}
+	return counts
+}
+
+// ManaCurve returns the number of nonland cards in the main deck at each
+// converted mana cost, counting every copy. Cards with X in their cost are
+// counted at their printed converted mana cost, with X as zero.
+func (d Deck) ManaCurve() map[int]int {
+	curve := make(map[int]int)
+	for card, count := range d.Main {
+		if !card.IsLand() {
+			curve[card.ConvertedManaCost] += count
+		}
+	}
+	return curve
+}
+
+// AverageCMC returns the average converted mana cost of the nonland cards
+// in the main deck, weighted by the number of copies of each. It returns 0
+// if there are no nonland cards.
+func (d Deck) AverageCMC() float64 {
+	var total, count int
+	for card, n := range d.Main {
+		if !card.IsLand() {
+			total += card.ConvertedManaCost * n
+			count += n
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return float64(total) / float64(count)
+}
+
+// sortedCards returns the cards in counts sorted by name, and then by
+// multiverseid for different printings of the same card.
+func sortedCards(counts map[Card]int) []Card {
+	cards := make([]Card, 0, len(counts))
+	for card := range counts {
+		cards = append(cards, card)
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		if cards[i].Name != cards[j].Name {
+			return cards[i].Name < cards[j].Name
+		}
+		return cards[i].MultiverseID < cards[j].MultiverseID
+	})
+	return cards
+}
+
+// countCards returns the total number of cards in counts, including copies.
+func countCards(counts map[Card]int) (total int) {
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
 
-	case Limited:
-		if d.Size() < 40 {
-			return ErrDeckTooSmall
+// add adds count copies of card to the main deck or sideboard, recording
+// its position if it's new. The maps must already be initialized.
+func (d *Deck) add(card Card, count int, isSideboard bool) {
+	counts, order := d.Main, &d.mainOrder
+	if isSideboard {
+		counts, order = d.Sideboard, &d.sideboardOrder
+	}
+	if _, ok := counts[card]; !ok {
+		*order = append(*order, card)
+	}
+	counts[card] += count
+}
+
+// Add adds count copies of card to the main deck. If the deck already holds
+// a card that is Equal to card, such as the same card without its
+// MultiverseID, the copies are added to that card instead.
+func (d *Deck) Add(card Card, count int) error {
+	return d.addChecked(card, count, false)
+}
+
+// AddSideboard adds count copies of card to the sideboard.
+func (d *Deck) AddSideboard(card Card, count int) error {
+	return d.addChecked(card, count, true)
+}
+
+// Remove removes up to count copies of card from the main deck. As with Add,
+// any card that is Equal to card matches. If no copies remain, the card is
+// removed entirely.
+func (d *Deck) Remove(card Card, count int) error {
+	return d.remove(card, count, false)
+}
+
+// RemoveSideboard removes up to count copies of card from the sideboard.
+func (d *Deck) RemoveSideboard(card Card, count int) error {
+	return d.remove(card, count, true)
+}
+
+func (d *Deck) addChecked(card Card, count int, isSideboard bool) error {
+	if count <= 0 {
+		return ErrInvalidCount
+	}
+	if d.Main == nil {
+		d.Main = make(map[Card]int)
+	}
+	if d.Sideboard == nil {
+		d.Sideboard = make(map[Card]int)
+	}
+	counts := d.Main
+	if isSideboard {
+		counts = d.Sideboard
+	}
+	d.add(matchingCard(counts, card), count, isSideboard)
+	return nil
+}
+
+// matchingCard returns the card in counts that is Equal to card, preferring
+// an identical one, or card itself if there is none.
+func matchingCard(counts map[Card]int, card Card) Card {
+	if _, ok := counts[card]; ok {
+		return card
+	}
+	for existing := range counts {
+		if existing.Equal(card) {
+			return existing
 		}
+	}
+	return card
+}
+
+func (d *Deck) remove(card Card, count int, isSideboard bool) error {
+	if count <= 0 {
+		return ErrInvalidCount
+	}
+	counts, order := d.Main, &d.mainOrder
+	if isSideboard {
+		counts, order = d.Sideboard, &d.sideboardOrder
+	}
+	card = matchingCard(counts, card)
+	n, ok := counts[card]
+	if !ok {
+		return nil
+	}
+	if n > count {
+		counts[card] = n - count
 		return nil
+	}
+	delete(counts, card)
+	for i, c := range *order {
+		if c == card {
+			// Copy instead of shifting in place, since copies of the Deck
+			// may share the same backing array.
+			*order = append((*order)[:i:i], (*order)[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
 
-	default:
-		return errors.New("unknown format")
+// Clone returns a deep copy of the deck, so that changes to the copy don't
+// affect the original.
+func (d Deck) Clone() Deck {
+	clone := Deck{
+		Main:           make(map[Card]int, len(d.Main)),
+		Sideboard:      make(map[Card]int, len(d.Sideboard)),
+		mainOrder:      append([]Card(nil), d.mainOrder...),
+		sideboardOrder: append([]Card(nil), d.sideboardOrder...),
+	}
+	for card, n := range d.Main {
+		clone.Main[card] = n
 	}
+	for card, n := range d.Sideboard {
+		clone.Sideboard[card] = n
+	}
+	if d.Commander != nil {
+		commander := *d.Commander
+		clone.Commander = &commander
+	}
+	if d.Companion != nil {
+		companion := *d.Companion
+		clone.Companion = &companion
+	}
+	return clone
 }
 
+// Merge returns a new deck containing the cards of both d and other, with
+// the counts of matching cards summed. Cards match if they are Equal.
+// Neither deck is modified.
+func (d Deck) Merge(other Deck) Deck {
+	merged := Deck{
+		Main:      make(map[Card]int),
+		Sideboard: make(map[Card]int),
+		Commander: d.Commander,
+		Companion: d.Companion,
+	}
+	if merged.Commander == nil {
+		merged.Commander = other.Commander
+	}
+	if merged.Companion == nil {
+		merged.Companion = other.Companion
+	}
+	merged.mergeFrom(d.MainEntries(), false)
+	merged.mergeFrom(other.MainEntries(), false)
+	merged.mergeFrom(d.SideboardEntries(), true)
+	merged.mergeFrom(other.SideboardEntries(), true)
+	return merged
+}
+
+// mergeFrom adds entries to the deck, combining them with any matching card
+// already present.
+func (d *Deck) mergeFrom(entries []DeckEntry, isSideboard bool) {
+	counts := d.Main
+	if isSideboard {
+		counts = d.Sideboard
+	}
+	for _, entry := range entries {
+		d.add(matchingCard(counts, entry.Card), entry.Count, isSideboard)
+	}
+}
+
+// DeckDiff describes the changes between two decks, as returned by
+// Deck.Diff.
+type DeckDiff struct {
+	Main      CardDiff
+	Sideboard CardDiff
+}
+
+// CardDiff describes the changes between two sets of cards. Added maps
+// new cards to the number of copies added, Removed maps cards that are
+// gone to the number of copies removed, and Changed maps cards present in
+// both to the change in their count, which may be negative.
+type CardDiff struct {
+	Added   map[Card]int
+	Removed map[Card]int
+	Changed map[Card]int
+}
+
+// Diff reports the changes needed to turn d into other, for both the main
+// deck and the sideboard. Cards are matched the same way as in Merge.
+func (d Deck) Diff(other Deck) DeckDiff {
+	return DeckDiff{
+		Main:      diffCards(d.Main, other.Main),
+		Sideboard: diffCards(d.Sideboard, other.Sideboard),
+	}
+}
+
+func diffCards(from, to map[Card]int) CardDiff {
+	diff := CardDiff{
+		Added:   make(map[Card]int),
+		Removed: make(map[Card]int),
+		Changed: make(map[Card]int),
+	}
+	matched := make(map[Card]bool)
+	for card, n := range from {
+		var (
+			m     int
+			found bool
+		)
+		for other, count := range to {
+			if card.Equal(other) {
+				m += count
+				matched[other] = true
+				found = true
+			}
+		}
+		switch {
+		case !found:
+			diff.Removed[card] = n
+		case m != n:
+			diff.Changed[card] = m - n
+		}
+	}
+	for card, n := range to {
+		if !matched[card] {
+			diff.Added[card] = n
+		}
+	}
+	return diff
+}
+
+// MainEntries returns the cards in the main deck in the order they were
+// added. Cards that were put in Main directly come last, sorted by name.
+func (d Deck) MainEntries() []DeckEntry {
+	return orderedEntries(d.Main, d.mainOrder)
+}
+
+// SideboardEntries is like MainEntries, but for the sideboard.
+func (d Deck) SideboardEntries() []DeckEntry {
+	return orderedEntries(d.Sideboard, d.sideboardOrder)
+}
+
+// SortedMain returns the cards in the main deck sorted the way decklists
+// usually are: grouped by type, with creatures first and lands last, then
+// by converted mana cost, then by name, and then by multiverseid for
+// different printings of the same card. Unlike ranging over Main, the
+// order is always the same.
+func (d Deck) SortedMain() []DeckEntry {
+	return sortedEntries(d.Main)
+}
+
+// SortedSideboard is like SortedMain, but for the sideboard.
+func (d Deck) SortedSideboard() []DeckEntry {
+	return sortedEntries(d.Sideboard)
+}
+
+func sortedEntries(counts map[Card]int) []DeckEntry {
+	entries := make([]DeckEntry, 0, len(counts))
+	for _, card := range sortedCards(counts) {
+		if n := counts[card]; n > 0 {
+			entries = append(entries, DeckEntry{card, n})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].Card, entries[j].Card
+		if ra, rb := typeRank(a), typeRank(b); ra != rb {
+			return ra < rb
+		}
+		return a.ConvertedManaCost < b.ConvertedManaCost
+	})
+	return entries
+}
+
+// sortTypes is the order in which SortedMain groups cards by type. Cards
+// with none of these types come after them, followed by lands.
+var sortTypes = []string{"Creature", "Planeswalker", "Instant", "Sorcery", "Artifact", "Enchantment"}
+
+// typeRank returns the position of card's group in the order used by
+// SortedMain. A card with more than one type, such as an artifact
+// creature, is grouped by the first of them in sortTypes.
+func typeRank(card Card) int {
+	if card.IsLand() {
+		return len(sortTypes) + 1
+	}
+	for i, t := range sortTypes {
+		if card.hasType(t) {
+			return i
+		}
+	}
+	return len(sortTypes)
+}
+
+func orderedEntries(counts map[Card]int, order []Card) []DeckEntry {
+	var (
+		entries = make([]DeckEntry, 0, len(counts))
+		seen    = make(map[Card]bool, len(counts))
+	)
+	for _, cards := range [][]Card{order, sortedCards(counts)} {
+		for _, card := range cards {
+			if n, ok := counts[card]; ok && n > 0 && !seen[card] {
+				seen[card] = true
+				entries = append(entries, DeckEntry{card, n})
+			}
+		}
+	}
+	return entries
+}
+
+// String returns the deck in the same .dec format as WriteTo.
 func (d Deck) String() string {
 	var buf bytes.Buffer
-	for c, n := range d.Main {
-		buf.WriteString(fmt.Sprintf("%d %s\n", n, c))
+	d.writeTo(&buf, true)
+	return buf.String()
+}
+
+// WriteTo writes the deck to w in .dec format, such that it can be read back
+// with NewDeck. Sideboard cards are written with an "SB:" prefix, and the
+// commander and companion, if any, are written first with "COMMANDER:" and
+// "COMPANION:" prefixes. The cards of each section are written in the order
+// given by SortedMain and SortedSideboard, so that the output is the same
+// for equal decks; use WriteInOrder to keep the order they were added in.
+func (d Deck) WriteTo(w io.Writer) (int64, error) {
+	return d.writeTo(w, true)
+}
+
+// WriteInOrder is like WriteTo, but writes cards in the order they were
+// added, as given by MainEntries and SideboardEntries.
+func (d Deck) WriteInOrder(w io.Writer) (int64, error) {
+	return d.writeTo(w, false)
+}
+
+// writeTo writes the deck to w in .dec format, with each section sorted as
+// by SortedMain if sorted is true, and in the order the cards were added
+// otherwise.
+func (d Deck) writeTo(w io.Writer, sorted bool) (int64, error) {
+	var total int64
+	for _, designated := range []struct {
+		prefix string
+		card   *Card
+	}{
+		{"COMMANDER: ", d.Commander},
+		{"COMPANION: ", d.Companion},
+	} {
+		if designated.card == nil {
+			continue
+		}
+		n, err := fmt.Fprintf(w, "%s%s\n", designated.prefix, designated.card.Name)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	main, sideboard := d.MainEntries(), d.SideboardEntries()
+	if sorted {
+		main, sideboard = d.SortedMain(), d.SortedSideboard()
 	}
-	if len(d.Sideboard) > 0 {
-		buf.WriteString("\nSideboard:\n")
-		for c, n := range d.Sideboard {
-			buf.WriteString(fmt.Sprintf("%d %s\n", n, c))
+	for _, section := range []struct {
+		prefix  string
+		entries []DeckEntry
+	}{
+		{"", main},
+		{"SB: ", sideboard},
+	} {
+		for _, entry := range section.entries {
+			n, err := fmt.Fprintf(w, "%s%d %s\n", section.prefix, entry.Count, entry.Card.Name)
+			total += int64(n)
+			if err != nil {
+				return total, err
+			}
 		}
 	}
-	return buf.String()
+	return total, nil
 }
 
 func parseCardLine(line string) (int, string, error) {
 	matches := decLineRe.FindStringSubmatch(line)
 	if matches == nil {
-		return 0, "", fmt.Errorf("line '%s' is not a valid card definition", line)
+		return 0, "", ParseError{Text: line}
+	}
+	if matches[1] == "" {
+		return 1, matches[2], nil
 	}
 
 	n, err := strconv.Atoi(matches[1])
 	if err != nil {
-		return 0, "", err
+		return 0, "", ParseError{Text: line}
 	}
 
 	return n, matches[2], nil
@@ -0,0 +1,152 @@
+package mtg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// stubProvider is a CardProvider that makes up a card for any name, so that
+// decks can be resolved without the network. Names starting with "//" are
+// treated as not found.
+type stubProvider struct{}
+
+func (stubProvider) FetchCard(ctx context.Context, multiverseid int) (Card, error) {
+	return Card{}, ErrCardNotFound
+}
+
+func (stubProvider) GetCardForName(ctx context.Context, name string) (Card, error) {
+	if strings.HasPrefix(name, "//") {
+		return Card{}, ErrCardNotFound
+	}
+	card := Card{Name: name, Type: "Instant"}
+	if name == "Forest" {
+		card.Type = "Basic Land — Forest"
+	}
+	return card, nil
+}
+
+// useStubProvider replaces DefaultProvider with a stubProvider until the
+// returned function is called.
+func useStubProvider() (restore func()) {
+	old := DefaultProvider
+	DefaultProvider = stubProvider{}
+	return func() { DefaultProvider = old }
+}
+
+// cachingProvider is like stubProvider, but goes through CardCache the same
+// way GetCardForName does.
+type cachingProvider struct{ stubProvider }
+
+func (p cachingProvider) GetCardForName(ctx context.Context, name string) (Card, error) {
+	if card, ok := cachedCard(name); ok {
+		return card, nil
+	}
+	card, err := p.stubProvider.GetCardForName(ctx, name)
+	if err == nil {
+		cacheCard(name, card)
+	}
+	return card, err
+}
+
+// TestNewDeckConcurrentLookups resolves a deck with many cards, several of
+// them repeated, so that lookups share the cache concurrently. Run it with
+// -race to catch unsynchronized access.
+func TestNewDeckConcurrentLookups(t *testing.T) {
+	old := DefaultProvider
+	DefaultProvider = cachingProvider{}
+	defer func() { DefaultProvider = old }()
+	ClearCardCache()
+	defer ClearCardCache()
+
+	var input strings.Builder
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&input, "1 Card %d\n", i)
+		fmt.Fprintf(&input, "SB: 1 Card %d\n", i%10)
+	}
+	for i := 0; i < 3; i++ {
+		deck, err := NewDeck(strings.NewReader(input.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := deck.Size(); got != 40 {
+			t.Errorf("main deck has %d cards, want 40", got)
+		}
+		if got := deck.SideboardSize(); got != 40 {
+			t.Errorf("sideboard has %d cards, want 40", got)
+		}
+	}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	var (
+		bolt     = Card{Name: "Lightning Bolt", Type: "Instant"}
+		mountain = Card{Name: "Mountain", Type: "Basic Land — Mountain"}
+		pyro     = Card{Name: "Pyroblast", Type: "Instant"}
+		lurrus   = Card{Name: "Lurrus of the Dream-Den", Type: "Legendary Creature — Cat Nightmare"}
+	)
+	var deck Deck
+	deck.Add(bolt, 4)
+	deck.Add(mountain, 20)
+	deck.AddSideboard(pyro, 2)
+	deck.Companion = &lurrus
+
+	clone := deck.Clone()
+	clone.Add(bolt, 1)
+	clone.Add(pyro, 1)
+	clone.Remove(mountain, 20)
+	clone.RemoveSideboard(pyro, 2)
+	clone.Companion.Name = "Kaheera, the Orphanguard"
+
+	if got := deck.Main[bolt]; got != 4 {
+		t.Errorf("original has %d Lightning Bolt, want 4", got)
+	}
+	if got := deck.Main[mountain]; got != 20 {
+		t.Errorf("original has %d Mountain, want 20", got)
+	}
+	if _, ok := deck.Main[pyro]; ok {
+		t.Error("original gained Pyroblast in the main deck")
+	}
+	if got := deck.Sideboard[pyro]; got != 2 {
+		t.Errorf("original has %d Pyroblast in the sideboard, want 2", got)
+	}
+	if deck.Companion.Name != "Lurrus of the Dream-Den" {
+		t.Errorf("original companion is %s", deck.Companion.Name)
+	}
+	if want := "COMPANION: Lurrus of the Dream-Den\n4 Lightning Bolt\n20 Mountain\nSB: 2 Pyroblast\n"; deck.String() != want {
+		t.Errorf("original written as\n%s\nwant\n%s", deck.String(), want)
+	}
+
+	if got := clone.Main[bolt]; got != 5 {
+		t.Errorf("clone has %d Lightning Bolt, want 5", got)
+	}
+	if _, ok := clone.Main[mountain]; ok {
+		t.Error("clone still has Mountain")
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	var (
+		forest = Card{Name: "Forest", Type: "Basic Land — Forest"}
+		growth = Card{Name: "Giant Growth", Type: "Instant"}
+		elves  = Card{Name: "Llanowar Elves", Type: "Creature — Elf Druid"}
+	)
+	var deck Deck
+	deck.Add(forest, 20)
+	deck.Add(growth, 4)
+	deck.Add(elves, 4)
+
+	var sorted, ordered strings.Builder
+	deck.WriteTo(&sorted)
+	deck.WriteInOrder(&ordered)
+	if want := "4 Llanowar Elves\n4 Giant Growth\n20 Forest\n"; sorted.String() != want {
+		t.Errorf("WriteTo wrote\n%s\nwant\n%s", sorted.String(), want)
+	}
+	if sorted.String() != deck.String() {
+		t.Errorf("WriteTo and String differ:\n%s\n%s", sorted.String(), deck.String())
+	}
+	if want := "20 Forest\n4 Giant Growth\n4 Llanowar Elves\n"; ordered.String() != want {
+		t.Errorf("WriteInOrder wrote\n%s\nwant\n%s", ordered.String(), want)
+	}
+}
@@ -0,0 +1,115 @@
+package mtg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// NewDeckAuto creates a new deck from the provided reader, guessing whether
+// it holds a .dec file, an MTG Arena export, or a Magic Online export from
+// its contents:
+//
+//   - Lines with an "SB:", "COMMANDER:", or "COMPANION:" prefix mean .dec,
+//     as read by NewDeck.
+//   - "Deck" or "Sideboard" headers, or set codes after card names as in
+//     "4 Lightning Bolt (M21) 159", mean MTG Arena, as read by
+//     NewDeckFromArena.
+//   - Exactly one blank line splitting the cards in two, with no more than
+//     15 cards after it and no comment lines, means Magic Online, as read
+//     by NewDeckFromMTGO. A .dec file may also use blank lines to group
+//     its cards, so a blank line alone isn't enough.
+//
+// If none of these match, the decklist is read as a .dec file. Cards are
+// looked up by name using DefaultProvider.
+func NewDeckAuto(r io.Reader) (Deck, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Deck{}, err
+	}
+	return newDeckAuto(context.Background(), data)
+}
+
+// NewDeckFromURL downloads the decklist at the given URL, such as a raw
+// paste or gist, using HTTPClient, and reads it like NewDeckAuto. An error
+// is returned if the server responds with any status other than 200 OK.
+func NewDeckFromURL(deckURL string) (Deck, error) {
+	return NewDeckFromURLContext(context.Background(), deckURL)
+}
+
+// NewDeckFromURLContext is like NewDeckFromURL, but the download and card
+// lookups are canceled if ctx is done before they complete.
+func NewDeckFromURLContext(ctx context.Context, deckURL string) (Deck, error) {
+	resp, err := get(ctx, deckURL)
+	if err != nil {
+		return Deck{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Deck{}, errors.New("NewDeckFromURL: unexpected response status: " + resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Deck{}, err
+	}
+	return newDeckAuto(ctx, data)
+}
+
+func newDeckAuto(ctx context.Context, data []byte) (Deck, error) {
+	list, err := detectFormat(data)(bytes.NewReader(data))
+	if err != nil {
+		return Deck{}, err
+	}
+	return list.resolve(ctx, DefaultProvider, nil)
+}
+
+// detectFormat returns the parser for the decklist format that data
+// appears to be in, as described by NewDeckAuto.
+func detectFormat(data []byte) func(io.Reader) (*deckList, error) {
+	var (
+		isArena, hasComment, afterBlank bool
+		// groups is the number of groups of card lines separated by blank
+		// lines, and lastGroup is the number of cards in the last of them.
+		groups, lastGroup int
+		scanner           = bufio.NewScanner(bytes.NewReader(data))
+	)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		upper := strings.ToUpper(line)
+		switch {
+		case line == "":
+			afterBlank = groups > 0
+		case strings.HasPrefix(upper, "SB:"), strings.HasPrefix(upper, "COMMANDER:"), strings.HasPrefix(upper, "COMPANION:"):
+			return parseDec
+		case upper == "DECK", upper == "SIDEBOARD", upper == "COMMANDER", upper == "COMPANION":
+			isArena = true
+		case isComment(line):
+			hasComment = true
+		default:
+			if matches := arenaLineRe.FindStringSubmatch(line); matches != nil && matches[3] != "" {
+				isArena = true
+			}
+			if groups == 0 || afterBlank {
+				groups++
+				lastGroup = 0
+				afterBlank = false
+			}
+			if count, _, err := parseCardLine(line); err == nil {
+				lastGroup += count
+			}
+		}
+	}
+	switch {
+	case isArena:
+		return parseArena
+	case groups == 2 && lastGroup <= 15 && !hasComment:
+		return parseMTGO
+	default:
+		return parseDec
+	}
+}
@@ -0,0 +1,64 @@
+package mtg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewDeckAuto(t *testing.T) {
+	defer useStubProvider()()
+
+	tests := []struct {
+		name            string
+		input           string
+		main, sideboard int
+	}{
+		{
+			name:  "dec grouped with comments",
+			input: "// Creatures\n4 Llanowar Elves\n\n// Spells\n4 Giant Growth\n\n// Lands\n20 Forest\n",
+			main:  28,
+		},
+		{
+			name:  "dec grouped without comments",
+			input: "4 Llanowar Elves\n\n4 Giant Growth\n\n20 Forest\n",
+			main:  28,
+		},
+		{
+			name:  "dec with large second group",
+			input: "4 Llanowar Elves\n\n4 Giant Growth\n20 Forest\n",
+			main:  28,
+		},
+		{
+			name:      "dec with SB prefix",
+			input:     "4 Giant Growth\nSB: 2 Naturalize\n",
+			main:      4,
+			sideboard: 2,
+		},
+		{
+			name:      "mtgo",
+			input:     "4 Llanowar Elves\n20 Forest\n\n3 Naturalize\n",
+			main:      24,
+			sideboard: 3,
+		},
+		{
+			name:      "arena",
+			input:     "Deck\n4 Llanowar Elves (M19) 314\n20 Forest\n\nSideboard\n3 Naturalize\n",
+			main:      24,
+			sideboard: 3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deck, err := NewDeckAuto(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := deck.Size(); got != test.main {
+				t.Errorf("main deck has %d cards, want %d", got, test.main)
+			}
+			if got := deck.SideboardSize(); got != test.sideboard {
+				t.Errorf("sideboard has %d cards, want %d", got, test.sideboard)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package mtg
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ResolveCardName searches Gatherer for card names close to name, for
+// correcting typos like "Lightnig Bolt". It returns every name within
+// maxDistance edits of name, ignoring case, ranked from closest to
+// farthest, so the first suggestion is the best match. An exact match is
+// always returned on its own.
+//
+// If searching for the full name finds nothing, each word of at least
+// three letters is searched for separately, so that a typo in one word
+// can still be matched by the others.
+func ResolveCardName(name string, maxDistance int) ([]string, error) {
+	return ResolveCardNameContext(context.Background(), name, maxDistance)
+}
+
+// ResolveCardNameContext is like ResolveCardName, but the search is canceled
+// if ctx is done before it completes.
+func ResolveCardNameContext(ctx context.Context, name string, maxDistance int) ([]string, error) {
+	candidates, err := searchNames(ctx, name)
+	if err != nil && err != ErrCardNotFound {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		for _, word := range strings.Fields(name) {
+			if len([]rune(word)) < 3 {
+				continue
+			}
+			names, err := searchNames(ctx, word)
+			if err != nil && err != ErrCardNotFound {
+				return nil, err
+			}
+			candidates = append(candidates, names...)
+		}
+	}
+
+	var (
+		target    = strings.ToLower(strings.TrimSpace(name))
+		distances = make(map[string]int)
+	)
+	for _, candidate := range candidates {
+		if _, ok := distances[candidate]; ok {
+			continue
+		}
+		d := editDistance(target, strings.ToLower(candidate))
+		if d == 0 {
+			return []string{candidate}, nil
+		}
+		if d <= maxDistance {
+			distances[candidate] = d
+		}
+	}
+
+	suggestions := make([]string, 0, len(distances))
+	for candidate := range distances {
+		suggestions = append(suggestions, candidate)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if distances[a] != distances[b] {
+			return distances[a] < distances[b]
+		}
+		return a < b
+	})
+	return suggestions, nil
+}
+
+// searchNames returns the names of the cards found by a Gatherer search for
+// query. If Gatherer redirects to its error page, the error is
+// ErrCardNotFound.
+func searchNames(ctx context.Context, query string) ([]string, error) {
+	resp, err := get(ctx, searchURL(query))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.Request.URL.Path {
+	case "/Pages/Error.aspx":
+		return nil, ErrCardNotFound
+	case "/Pages/Card/Details.aspx":
+		card, err := ParseCard(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return []string{card.Name}, nil
+	case "/Pages/Search/Default.aspx":
+		doc, err := html.Parse(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		results, err := parseSearchResults(doc, resp.Request.URL.Path)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, len(results))
+		for i, result := range results {
+			names[i] = result.Name
+		}
+		return names, nil
+	default:
+		return nil, errors.New("searchNames: unknown url path: " + resp.Request.URL.Path)
+	}
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	var (
+		s, t = []rune(a), []rune(b)
+		prev = make([]int, len(t)+1)
+		cur  = make([]int, len(t)+1)
+	)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if n := prev[j] + 1; n < cur[j] {
+				cur[j] = n
+			}
+			if n := cur[j-1] + 1; n < cur[j] {
+				cur[j] = n
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(t)]
+}
@@ -0,0 +1,193 @@
+package mtg
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// DrawHand shuffles the main deck and returns the top n cards. An error is
+// returned if the deck has fewer than n cards.
+func (d Deck) DrawHand(n int) ([]Card, error) {
+	return d.drawHand(n, rand.New(rand.NewSource(time.Now().UnixNano())))
+}
+
+// DrawHandSeed is like DrawHand, but shuffles using the provided seed so
+// that the same deck and seed always produce the same hand.
+func (d Deck) DrawHandSeed(n int, seed int64) ([]Card, error) {
+	return d.drawHand(n, rand.New(rand.NewSource(seed)))
+}
+
+func (d Deck) drawHand(n int, rng *rand.Rand) ([]Card, error) {
+	if n < 0 {
+		return nil, errors.New("hand size must not be negative")
+	}
+	library := d.library()
+	if len(library) < n {
+		return nil, ErrDeckTooSmall
+	}
+	shuffle(library, rng)
+	return library[:n], nil
+}
+
+// shuffle shuffles cards in place using rng.
+func shuffle(cards []Card, rng *rand.Rand) {
+	rng.Shuffle(len(cards), func(i, j int) {
+		cards[i], cards[j] = cards[j], cards[i]
+	})
+}
+
+// library returns every card in the main deck, one entry per copy. Cards
+// are sorted by name first so that shuffling with a fixed seed doesn't
+// depend on map iteration order.
+func (d Deck) library() []Card {
+	library := make([]Card, 0, d.Size())
+	for _, card := range sortedCards(d.Main) {
+		for i := 0; i < d.Main[card]; i++ {
+			library = append(library, card)
+		}
+	}
+	return library
+}
+
+// SimulateOptions configures Deck.Simulate.
+type SimulateOptions struct {
+	// Iterations is the number of games to play out. If zero, 1000 are
+	// played.
+	Iterations int
+	// HandSize is the size of the opening hand. If zero, 7 is used.
+	HandSize int
+	// Turns is the number of turns to play out in each game.
+	Turns int
+	// OnTheDraw draws an extra card on the first turn, as the player who
+	// goes second does.
+	OnTheDraw bool
+	// Seed seeds the shuffles, so that the same deck and options always
+	// produce the same result. If zero, a random seed is used.
+	Seed int64
+}
+
+// SimulateResult holds the statistics gathered by Deck.Simulate.
+type SimulateResult struct {
+	// AverageOpeningLands is the average number of lands in the opening
+	// hand.
+	AverageOpeningLands float64
+	// LandDrops holds, for each turn, the probability of having made a
+	// land drop on every turn up to and including it. LandDrops[0] is
+	// turn 1.
+	LandDrops []float64
+}
+
+// Simulate "goldfishes" the main deck: it repeatedly shuffles, draws an
+// opening hand, and draws a card each turn, playing a land every turn it
+// can, then reports how the draws went. No mulligans are taken. An error
+// is returned if the deck has too few cards to play out every turn.
+func (d Deck) Simulate(opts SimulateOptions) (SimulateResult, error) {
+	if opts.Iterations == 0 {
+		opts.Iterations = 1000
+	}
+	if opts.HandSize == 0 {
+		opts.HandSize = 7
+	}
+	if opts.Iterations < 0 || opts.HandSize < 0 || opts.Turns < 0 {
+		return SimulateResult{}, errors.New("simulation options must not be negative")
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	// seen returns the number of cards seen by the end of the given turn.
+	seen := func(turn int) int {
+		n := opts.HandSize + turn - 1
+		if opts.OnTheDraw {
+			n++
+		}
+		return n
+	}
+
+	library := d.library()
+	if len(library) < seen(opts.Turns) {
+		return SimulateResult{}, ErrDeckTooSmall
+	}
+
+	var (
+		openingLands int
+		landDrops    = make([]int, opts.Turns)
+	)
+	for i := 0; i < opts.Iterations; i++ {
+		shuffle(library, rng)
+		openingLands += countLands(library[:opts.HandSize])
+		for turn := 1; turn <= opts.Turns; turn++ {
+			if countLands(library[:seen(turn)]) < turn {
+				break
+			}
+			landDrops[turn-1]++
+		}
+	}
+
+	result := SimulateResult{
+		AverageOpeningLands: float64(openingLands) / float64(opts.Iterations),
+		LandDrops:           make([]float64, opts.Turns),
+	}
+	for i, n := range landDrops {
+		result.LandDrops[i] = float64(n) / float64(opts.Iterations)
+	}
+	return result, nil
+}
+
+// ShouldMulligan reports whether hand should be mulliganed because it has
+// fewer than minLands or more than maxLands lands.
+func ShouldMulligan(hand []Card, minLands, maxLands int) bool {
+	lands := countLands(hand)
+	return lands < minLands || lands > maxLands
+}
+
+// countLands returns the number of lands in cards.
+func countLands(cards []Card) (n int) {
+	for _, card := range cards {
+		if card.IsLand() {
+			n++
+		}
+	}
+	return n
+}
+
+// LandProbability returns the probability of drawing at least wantLands
+// lands in an opening hand of handSize cards, using the hypergeometric
+// distribution. It returns 0 if the hand is larger than the deck or if the
+// deck doesn't contain wantLands lands.
+func (d Deck) LandProbability(handSize, wantLands int) float64 {
+	var (
+		size        = d.Size()
+		_, numLands = d.Lands()
+	)
+	if handSize < 0 || handSize > size || wantLands > numLands || wantLands > handSize {
+		return 0
+	}
+	if wantLands <= 0 {
+		return 1
+	}
+
+	var p float64
+	for k := wantLands; k <= handSize && k <= numLands; k++ {
+		p += choose(numLands, k) * choose(size-numLands, handSize-k)
+	}
+	return p / choose(size, handSize)
+}
+
+// choose returns the binomial coefficient "n choose k".
+func choose(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	if k > n-k {
+		k = n - k
+	}
+	c := 1.0
+	for i := 1; i <= k; i++ {
+		c = c * float64(n-k+i) / float64(i)
+	}
+	return c
+}
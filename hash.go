@@ -0,0 +1,43 @@
+package mtg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Hash returns a hash of the cards in the main deck and their counts, as a
+// hex string, for finding duplicate decks. Cards are identified by name, so
+// different printings of the same card count as the same card, and the
+// order in which cards were added doesn't matter: two decks with the same
+// cards and counts always have the same hash.
+func (d Deck) Hash() string {
+	h := sha256.New()
+	writeHashSection(h, "main", d.Main)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// HashWithSideboard is like Hash, but covers the sideboard as well. It is
+// different from Hash even for decks without a sideboard.
+func (d Deck) HashWithSideboard() string {
+	h := sha256.New()
+	writeHashSection(h, "main", d.Main)
+	writeHashSection(h, "sideboard", d.Sideboard)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeHashSection writes the named section to w with one line per card
+// name, in sorted order.
+func writeHashSection(w io.Writer, name string, cards map[Card]int) {
+	counts := make(map[string]int, len(cards))
+	for card, n := range cards {
+		counts[card.Name] += n
+	}
+	fmt.Fprintf(w, "%s\n", name)
+	for _, name := range sortedNames(counts) {
+		if counts[name] > 0 {
+			fmt.Fprintf(w, "%d %s\n", counts[name], name)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package mtg
+
+import "encoding/json"
+
+// deckJSON is the JSON representation of a Deck. Cards can't be used as
+// JSON object keys, so each section is a list of entries instead of a map.
+type deckJSON struct {
+	Main      []DeckEntry `json:"main"`
+	Sideboard []DeckEntry `json:"sideboard,omitempty"`
+	Commander *Card       `json:"commander,omitempty"`
+	Companion *Card       `json:"companion,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. The main deck and sideboard are
+// written as arrays of {"card": ..., "count": ...} objects, in the same
+// order as MainEntries and SideboardEntries.
+func (d Deck) MarshalJSON() ([]byte, error) {
+	return json.Marshal(deckJSON{
+		Main:      d.MainEntries(),
+		Sideboard: d.SideboardEntries(),
+		Commander: d.Commander,
+		Companion: d.Companion,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Deck) UnmarshalJSON(data []byte) error {
+	var v deckJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	deck := Deck{
+		Main:      make(map[Card]int),
+		Sideboard: make(map[Card]int),
+		Commander: v.Commander,
+		Companion: v.Companion,
+	}
+	for _, entry := range v.Main {
+		deck.add(entry.Card, entry.Count, false)
+	}
+	for _, entry := range v.Sideboard {
+		deck.add(entry.Card, entry.Count, true)
+	}
+	*d = deck
+	return nil
+}
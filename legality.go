@@ -0,0 +1,79 @@
+package mtg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Legalities maps the name of a format, as Gatherer writes it, such as
+// "Commander" or "Vintage", to the card's status in that format: "Legal",
+// "Banned", or "Restricted". Formats in which the card isn't legal at all
+// are usually left out.
+//
+// Legalities aren't stored on Card itself, since Cards must stay
+// comparable to be used as map keys.
+type Legalities map[string]string
+
+// FetchLegalities retrieves the formats the card with the given
+// multiverseid is legal, banned, or restricted in from Gatherer. If the
+// page has no legality section, the result is empty and the error is nil.
+func FetchLegalities(multiverseid int) (Legalities, error) {
+	return FetchLegalitiesContext(context.Background(), multiverseid)
+}
+
+// FetchLegalitiesContext is like FetchLegalities, but the request is
+// canceled if ctx is done before it completes.
+func FetchLegalitiesContext(ctx context.Context, multiverseid int) (Legalities, error) {
+	// Legalities are listed on the printings page rather than the details
+	// page.
+	resp, err := get(ctx, fmt.Sprintf(GathererBaseURL+"/Pages/Card/Printings.aspx?multiverseid=%d", multiverseid))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return parseLegalities(resp.Body)
+}
+
+// parseLegalities reads the legality table of a Gatherer printings page,
+// which is the cardList table whose header has a "Legality" column.
+func parseLegalities(r io.Reader) (Legalities, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	legalities := make(Legalities)
+	tables := findAllNodes(doc, func(node *html.Node) bool {
+		return node.Type == html.ElementNode && node.Data == "table" && nodeHasClass(node, "cardList")
+	})
+	for _, table := range tables {
+		header := findNode(table, func(node *html.Node) bool {
+			return node.Type == html.ElementNode && node.Data == "tr" && nodeHasClass(node, "headerRow")
+		})
+		if header == nil || !strings.Contains(nodeText(header), "Legality") {
+			continue
+		}
+		rows := findAllNodes(table, func(node *html.Node) bool {
+			return node.Type == html.ElementNode && node.Data == "tr" && nodeHasClass(node, "cardItem")
+		})
+		for _, row := range rows {
+			cells := findAllNodes(row, func(node *html.Node) bool {
+				return node.Type == html.ElementNode && node.Data == "td"
+			})
+			if len(cells) < 2 {
+				continue
+			}
+			format := strings.TrimSpace(nodeText(cells[0]))
+			status := strings.TrimSpace(nodeText(cells[1]))
+			if format != "" && status != "" {
+				legalities[format] = status
+			}
+		}
+	}
+	return legalities, nil
+}
@@ -0,0 +1,51 @@
+package mtg
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+)
+
+// NewDeckFromMTGO creates a new deck from the provided reader, which should
+// provide a decklist exported from Magic Online as a .txt file. Each line
+// holds a count and a card name separated by a space or a tab, and every
+// card after the first blank line is in the sideboard. Cards are looked up
+// by name using DefaultProvider.
+func NewDeckFromMTGO(r io.Reader) (Deck, error) {
+	list, err := parseMTGO(r)
+	if err != nil {
+		return Deck{}, err
+	}
+	return list.resolve(context.Background(), DefaultProvider, nil)
+}
+
+func parseMTGO(r io.Reader) (*deckList, error) {
+	var (
+		list        = newDeckList()
+		isSideboard bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			if len(list.mainOrder) > 0 {
+				isSideboard = true
+			}
+			continue
+		}
+		if isComment(line) {
+			continue
+		}
+
+		count, cardName, err := parseCardLine(line)
+		if err != nil {
+			return nil, ParseError{lineNum, scanner.Text()}
+		}
+
+		list.add(cardName, count, isSideboard)
+	}
+
+	return list, scanner.Err()
+}
@@ -0,0 +1,204 @@
+package mtg
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OfflineProvider is a CardProvider that serves cards from a local MTGJSON
+// file instead of the network. Like the other providers, it looks up names
+// without regard to case.
+type OfflineProvider struct {
+	byName map[string]Card
+	byID   map[int]Card
+}
+
+var _ CardProvider = (*OfflineProvider)(nil)
+
+// mtgjsonCard holds the fields of an MTGJSON card object that map onto
+// Card. Older versions of MTGJSON use convertedManaCost instead of
+// manaValue.
+type mtgjsonCard struct {
+	Name              string   `json:"name"`
+	FaceName          string   `json:"faceName"`
+	Side              string   `json:"side"`
+	Layout            string   `json:"layout"`
+	ManaCost          string   `json:"manaCost"`
+	ManaValue         float64  `json:"manaValue"`
+	ConvertedManaCost float64  `json:"convertedManaCost"`
+	FaceManaValue     float64  `json:"faceManaValue"`
+	Type              string   `json:"type"`
+	Text              string   `json:"text"`
+	ColorIndicator    []string `json:"colorIndicator"`
+	FlavorText        string   `json:"flavorText"`
+	Power             string   `json:"power"`
+	Toughness         string   `json:"toughness"`
+	Loyalty           string   `json:"loyalty"`
+	Rarity            string   `json:"rarity"`
+	Artist            string   `json:"artist"`
+	Identifiers       struct {
+		MultiverseID string `json:"multiverseId"`
+	} `json:"identifiers"`
+}
+
+// NewOfflineProvider loads the MTGJSON file at path and indexes its cards
+// by name. Both the AtomicCards format, which maps each name to a list of
+// faces under "data", and the older AllCards format, which maps each name
+// directly to a card, are supported. Cards with more than one face are
+// built the same way as by ScryfallProvider.
+func NewOfflineProvider(path string) (*OfflineProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, err
+	}
+
+	cards := make(map[string]Card)
+	if raw, ok := top["data"]; ok {
+		var atomic map[string][]mtgjsonCard
+		if err := json.Unmarshal(raw, &atomic); err != nil {
+			return nil, err
+		}
+		for name, faces := range atomic {
+			if len(faces) > 0 {
+				cards[name] = facesCard(faces)
+			}
+		}
+	} else {
+		var all map[string]mtgjsonCard
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+		for name, mc := range all {
+			cards[name] = mc.card()
+		}
+	}
+
+	p := &OfflineProvider{
+		byName: make(map[string]Card, len(cards)),
+		byID:   make(map[int]Card),
+	}
+	for name, card := range cards {
+		if card.Name == "" {
+			card.Name = name
+		}
+		p.byName[nameKey(name)] = card
+		// A double-faced card is listed under the names of both of its
+		// faces joined by " // ", but its Name is that of its front face
+		// alone, which is how decklists usually refer to it.
+		if _, ok := p.byName[nameKey(card.Name)]; !ok {
+			p.byName[nameKey(card.Name)] = card
+		}
+		if card.MultiverseID != 0 {
+			p.byID[card.MultiverseID] = card
+		}
+	}
+	return p, nil
+}
+
+// nameKey returns the key under which OfflineProvider indexes the card
+// with the given name.
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// FetchCard returns the card with the given multiverseid. MTGJSON's atomic
+// card files don't include multiverseids, so this only finds cards from
+// files that do.
+func (p *OfflineProvider) FetchCard(ctx context.Context, multiverseid int) (Card, error) {
+	if card, ok := p.byID[multiverseid]; ok {
+		return card, nil
+	}
+	return Card{}, ErrCardNotFound
+}
+
+func (p *OfflineProvider) GetCardForName(ctx context.Context, name string) (Card, error) {
+	if card, ok := p.byName[nameKey(name)]; ok {
+		return card, nil
+	}
+	return Card{}, ErrCardNotFound
+}
+
+// card converts an MTGJSON card object into a Card.
+func (mc mtgjsonCard) card() Card {
+	card := Card{
+		Name:       mc.Name,
+		ManaCost:   mc.ManaCost,
+		Type:       mc.Type,
+		Text:       mc.Text,
+		FlavorText: mc.FlavorText,
+		Rarity:     rarityName(mc.Rarity),
+		Artist:     mc.Artist,
+	}
+	card.MultiverseID, _ = strconv.Atoi(mc.Identifiers.MultiverseID)
+	if mc.ManaValue != 0 {
+		card.ConvertedManaCost = int(mc.ManaValue)
+	} else {
+		card.ConvertedManaCost = int(mc.ConvertedManaCost)
+	}
+	card.ColorIndicator = colorString(mc.ColorIndicator)
+	if strings.Contains(card.Type, "Planeswalker") {
+		card.Loyalty, _ = strconv.Atoi(mc.Loyalty)
+	} else {
+		card.Power, card.Toughness = mc.Power, mc.Toughness
+	}
+	return card
+}
+
+// facesCard converts the MTGJSON card objects for each face of a card into
+// a single Card. As with ParseCard, a split card is described by both of
+// its halves, and any other multi-faced card by its front face.
+func facesCard(faces []mtgjsonCard) Card {
+	sort.SliceStable(faces, func(i, j int) bool {
+		return faces[i].Side < faces[j].Side
+	})
+	card := faces[0].card()
+	if len(faces) > 1 {
+		for i := 0; i < len(faces) && i < len(card.Faces); i++ {
+			card.Faces[i] = faces[i].face()
+		}
+		if faces[0].Layout == "split" {
+			card.combineHalves(faces[0].Name)
+		} else {
+			card.setFace(card.Faces[0])
+		}
+	}
+	return card
+}
+
+// face converts the MTGJSON card object for one face of a card into a
+// CardFace. Older versions of MTGJSON don't give a face its own mana value,
+// so it's computed from the face's mana cost if it's missing.
+func (mc mtgjsonCard) face() CardFace {
+	face := CardFace{
+		Name:           mc.FaceName,
+		ManaCost:       mc.ManaCost,
+		Type:           mc.Type,
+		Text:           mc.Text,
+		ColorIndicator: colorString(mc.ColorIndicator),
+		FlavorText:     mc.FlavorText,
+		Artist:         mc.Artist,
+	}
+	if face.Name == "" {
+		face.Name = mc.Name
+	}
+	if mc.FaceManaValue != 0 {
+		face.ConvertedManaCost = int(mc.FaceManaValue)
+	} else {
+		face.ConvertedManaCost = manaValue(manaSymbols(mc.ManaCost))
+	}
+	if strings.Contains(face.Type, "Planeswalker") {
+		face.Loyalty, _ = strconv.Atoi(mc.Loyalty)
+	} else {
+		face.Power, face.Toughness = mc.Power, mc.Toughness
+	}
+	return face
+}
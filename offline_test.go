@@ -0,0 +1,69 @@
+package mtg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const atomicCards = `{
+	"meta": {},
+	"data": {
+		"Fire // Ice": [
+			{"name": "Fire // Ice", "faceName": "Fire", "side": "a", "layout": "split", "manaCost": "{1}{R}", "manaValue": 4, "type": "Instant", "text": "Fire deals 2 damage divided as you choose among one or two targets."},
+			{"name": "Fire // Ice", "faceName": "Ice", "side": "b", "layout": "split", "manaCost": "{1}{U}", "manaValue": 4, "type": "Instant", "text": "Tap target permanent.\nDraw a card."}
+		],
+		"Delver of Secrets // Insectile Aberration": [
+			{"name": "Delver of Secrets // Insectile Aberration", "faceName": "Insectile Aberration", "side": "b", "layout": "transform", "manaValue": 1, "type": "Creature — Human Insect", "text": "Flying", "colorIndicator": ["U"], "power": "3", "toughness": "2"},
+			{"name": "Delver of Secrets // Insectile Aberration", "faceName": "Delver of Secrets", "side": "a", "layout": "transform", "manaCost": "{U}", "manaValue": 1, "type": "Creature — Human Wizard", "power": "1", "toughness": "1"}
+		],
+		"Lightning Bolt": [
+			{"name": "Lightning Bolt", "layout": "normal", "manaCost": "{R}", "manaValue": 1, "type": "Instant", "text": "Lightning Bolt deals 3 damage to any target."}
+		]
+	}
+}`
+
+func TestOfflineProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "AtomicCards.json")
+	if err := os.WriteFile(path, []byte(atomicCards), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewOfflineProvider(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	bolt, err := p.GetCardForName(ctx, "lightning bolt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bolt.Name != "Lightning Bolt" || bolt.IsMultiFaced() {
+		t.Errorf("unexpected card: %+v", bolt)
+	}
+
+	fireIce, err := p.GetCardForName(ctx, "Fire // Ice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fireIce.IsSplit() || fireIce.ManaCost != "{1}{R}{1}{U}" || fireIce.ConvertedManaCost != 4 {
+		t.Errorf("Fire // Ice not combined: %+v", fireIce)
+	}
+	if colors := fireIce.ColorIdentity(); len(colors) != 2 {
+		t.Errorf("got color identity %v, want both halves' colors", colors)
+	}
+
+	for _, name := range []string{"Delver of Secrets", "Delver of Secrets // Insectile Aberration"} {
+		delver, err := p.GetCardForName(ctx, name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if delver.Name != "Delver of Secrets" || delver.Power != "1" || delver.ConvertedManaCost != 1 {
+			t.Errorf("%s: front face fields not used: %+v", name, delver)
+		}
+		if back := delver.Faces[1]; back.Name != "Insectile Aberration" || back.ColorIndicator != "U" {
+			t.Errorf("%s: unexpected back face: %+v", name, back)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package mtg
+
+import "context"
+
+// TotalPrice returns the total price of the main deck according to p,
+// counting every copy. Cards without a known price aren't counted toward
+// the total, and are returned in missing, sorted by name, instead. If a
+// lookup fails, TotalPrice stops and returns the error.
+func (d Deck) TotalPrice(ctx context.Context, p PriceProvider) (total float64, missing []Card, err error) {
+	for _, card := range sortedCards(d.Main) {
+		price, ok, err := p.Price(ctx, card)
+		if err != nil {
+			return 0, nil, err
+		}
+		if !ok {
+			missing = append(missing, card)
+			continue
+		}
+		total += price * float64(d.Main[card])
+	}
+	return total, missing, nil
+}
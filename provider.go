@@ -0,0 +1,46 @@
+package mtg
+
+import "context"
+
+// DefaultProvider is the CardProvider used by functions that don't take one
+// explicitly, such as NewDeck. It can be replaced to use a different source
+// of card data everywhere, such as a stub in tests.
+var DefaultProvider CardProvider = GathererProvider{}
+
+var (
+	_ CardProvider  = GathererProvider{}
+	_ CardProvider  = ScryfallProvider{}
+	_ PriceProvider = ScryfallProvider{}
+)
+
+// CardProvider is a source of card data. Implementations must be safe for
+// concurrent use, since NewDeck looks up several cards at once.
+type CardProvider interface {
+	// FetchCard retrieves a card given its multiverseid. If the card
+	// simply wasn't found, the error is ErrCardNotFound.
+	FetchCard(ctx context.Context, multiverseid int) (Card, error)
+	// GetCardForName retrieves a card given its exact name. If the card
+	// simply wasn't found, the error is ErrCardNotFound.
+	GetCardForName(ctx context.Context, name string) (Card, error)
+}
+
+// PriceProvider is a source of card prices. Implementations must be safe for
+// concurrent use.
+type PriceProvider interface {
+	// Price returns the price of a single copy of card in US dollars. If
+	// no price is known for the card, ok is false and err is nil.
+	Price(ctx context.Context, card Card) (price float64, ok bool, err error)
+}
+
+// GathererProvider is a CardProvider that scrapes Gatherer. It is
+// equivalent to calling FetchCardContext and GetCardForNameContext, and
+// shares the same cache.
+type GathererProvider struct{}
+
+func (GathererProvider) FetchCard(ctx context.Context, multiverseid int) (Card, error) {
+	return FetchCardContext(ctx, multiverseid)
+}
+
+func (GathererProvider) GetCardForName(ctx context.Context, name string) (Card, error) {
+	return GetCardForNameContext(ctx, name)
+}
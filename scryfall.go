@@ -0,0 +1,217 @@
+package mtg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const scryfallBase = "https://api.scryfall.com"
+
+// ScryfallProvider is a CardProvider backed by the Scryfall JSON API. Unlike
+// GathererProvider, it doesn't cache results.
+type ScryfallProvider struct {
+	// BaseURL is the base URL of the Scryfall API. If empty, the public API
+	// at https://api.scryfall.com is used.
+	BaseURL string
+}
+
+// scryfallCard holds the fields of a Scryfall card object that map onto
+// Card.
+type scryfallCard struct {
+	Name            string   `json:"name"`
+	MultiverseIDs   []int    `json:"multiverse_ids"`
+	ManaCost        string   `json:"mana_cost"`
+	CMC             float64  `json:"cmc"`
+	TypeLine        string   `json:"type_line"`
+	OracleText      string   `json:"oracle_text"`
+	ColorIndicator  []string `json:"color_indicator"`
+	FlavorText      string   `json:"flavor_text"`
+	Power           string   `json:"power"`
+	Toughness       string   `json:"toughness"`
+	Loyalty         string   `json:"loyalty"`
+	Set             string   `json:"set"`
+	SetName         string   `json:"set_name"`
+	CollectorNumber string   `json:"collector_number"`
+	Rarity          string   `json:"rarity"`
+	Artist          string   `json:"artist"`
+	Layout          string   `json:"layout"`
+	// CardFaces holds the faces of multi-faced cards, such as split and
+	// double-faced cards. Scryfall leaves the face-specific fields of the
+	// card itself, such as OracleText, empty for most of them.
+	CardFaces []scryfallFace `json:"card_faces"`
+	Prices    struct {
+		USD string `json:"usd"`
+	} `json:"prices"`
+}
+
+// scryfallFace holds the fields of a Scryfall card face object that map
+// onto CardFace.
+type scryfallFace struct {
+	Name           string   `json:"name"`
+	ManaCost       string   `json:"mana_cost"`
+	CMC            *float64 `json:"cmc"`
+	TypeLine       string   `json:"type_line"`
+	OracleText     string   `json:"oracle_text"`
+	ColorIndicator []string `json:"color_indicator"`
+	FlavorText     string   `json:"flavor_text"`
+	Power          string   `json:"power"`
+	Toughness      string   `json:"toughness"`
+	Loyalty        string   `json:"loyalty"`
+	Artist         string   `json:"artist"`
+}
+
+func (p ScryfallProvider) FetchCard(ctx context.Context, multiverseid int) (Card, error) {
+	sc, err := p.getCard(ctx, multiversePath(multiverseid))
+	if err != nil {
+		return Card{}, err
+	}
+	return sc.card(), nil
+}
+
+func (p ScryfallProvider) GetCardForName(ctx context.Context, name string) (Card, error) {
+	sc, err := p.getCard(ctx, namedPath(name))
+	if err != nil {
+		return Card{}, err
+	}
+	return sc.card(), nil
+}
+
+// Price returns Scryfall's price for card in US dollars. The card is looked
+// up by its MultiverseID if it's known, and by name otherwise.
+func (p ScryfallProvider) Price(ctx context.Context, card Card) (float64, bool, error) {
+	path := namedPath(card.Name)
+	if card.MultiverseID != 0 {
+		path = multiversePath(card.MultiverseID)
+	}
+	sc, err := p.getCard(ctx, path)
+	if err == ErrCardNotFound {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	if sc.Prices.USD == "" {
+		return 0, false, nil
+	}
+	price, err := strconv.ParseFloat(sc.Prices.USD, 64)
+	if err != nil {
+		return 0, false, errors.New("scryfall: " + err.Error())
+	}
+	return price, true, nil
+}
+
+func multiversePath(multiverseid int) string {
+	return "/cards/multiverse/" + strconv.Itoa(multiverseid)
+}
+
+func namedPath(name string) string {
+	return "/cards/named?" + url.Values{"exact": {name}}.Encode()
+}
+
+func (p ScryfallProvider) getCard(ctx context.Context, path string) (scryfallCard, error) {
+	base := p.BaseURL
+	if base == "" {
+		base = scryfallBase
+	}
+	resp, err := get(ctx, base+path)
+	if err != nil {
+		return scryfallCard{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return scryfallCard{}, ErrCardNotFound
+	default:
+		return scryfallCard{}, errors.New("scryfall: unexpected response status: " + resp.Status)
+	}
+
+	var sc scryfallCard
+	if err := json.NewDecoder(resp.Body).Decode(&sc); err != nil {
+		return scryfallCard{}, errors.New("scryfall: " + err.Error())
+	}
+	return sc, nil
+}
+
+// card converts a Scryfall card object into a Card.
+func (sc scryfallCard) card() Card {
+	card := Card{
+		Name:              sc.Name,
+		ManaCost:          sc.ManaCost,
+		ConvertedManaCost: int(sc.CMC),
+		Type:              sc.TypeLine,
+		Text:              sc.OracleText,
+		FlavorText:        sc.FlavorText,
+		Set:               sc.SetName,
+		SetCode:           strings.ToUpper(sc.Set),
+		Number:            sc.CollectorNumber,
+		Rarity:            rarityName(sc.Rarity),
+		Artist:            sc.Artist,
+	}
+	if len(sc.MultiverseIDs) > 0 {
+		card.MultiverseID = sc.MultiverseIDs[0]
+	}
+	card.ColorIndicator = colorString(sc.ColorIndicator)
+	if strings.Contains(card.Type, "Planeswalker") {
+		card.Loyalty, _ = strconv.Atoi(sc.Loyalty)
+	} else {
+		card.Power, card.Toughness = sc.Power, sc.Toughness
+	}
+	if len(sc.CardFaces) > 1 {
+		for i := 0; i < len(sc.CardFaces) && i < len(card.Faces); i++ {
+			card.Faces[i] = sc.CardFaces[i].face()
+		}
+		// Like ParseCard, describe a split card by both of its halves, and
+		// any other multi-faced card by its front face.
+		if sc.Layout == "split" {
+			card.combineHalves(sc.Name)
+		} else {
+			card.setFace(card.Faces[0])
+		}
+	}
+	return card
+}
+
+// face converts a Scryfall card face object into a CardFace. Scryfall
+// rarely gives a face its own converted mana cost, so it's usually
+// computed from the face's mana cost.
+func (sf scryfallFace) face() CardFace {
+	face := CardFace{
+		Name:           sf.Name,
+		ManaCost:       sf.ManaCost,
+		Type:           sf.TypeLine,
+		Text:           sf.OracleText,
+		ColorIndicator: colorString(sf.ColorIndicator),
+		FlavorText:     sf.FlavorText,
+		Artist:         sf.Artist,
+	}
+	if sf.CMC != nil {
+		face.ConvertedManaCost = int(*sf.CMC)
+	} else {
+		face.ConvertedManaCost = manaValue(manaSymbols(sf.ManaCost))
+	}
+	if strings.Contains(face.Type, "Planeswalker") {
+		face.Loyalty, _ = strconv.Atoi(sf.Loyalty)
+	} else {
+		face.Power, face.Toughness = sf.Power, sf.Toughness
+	}
+	return face
+}
+
+// rarityName converts a lowercase rarity as used by Scryfall and MTGJSON,
+// such as "mythic", into the name Gatherer uses.
+func rarityName(rarity string) string {
+	switch rarity {
+	case "":
+		return ""
+	case "mythic":
+		return "Mythic Rare"
+	default:
+		return strings.ToUpper(rarity[:1]) + rarity[1:]
+	}
+}
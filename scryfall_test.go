@@ -0,0 +1,106 @@
+package mtg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestScryfallCardFaces(t *testing.T) {
+	pages := map[string]string{
+		"Delver of Secrets": `{
+			"name": "Delver of Secrets // Insectile Aberration",
+			"layout": "transform",
+			"cmc": 1,
+			"type_line": "Creature — Human Wizard // Creature — Human Insect",
+			"set": "isd",
+			"set_name": "Innistrad",
+			"rarity": "common",
+			"artist": "Nils Hamm",
+			"card_faces": [
+				{
+					"name": "Delver of Secrets",
+					"mana_cost": "{U}",
+					"type_line": "Creature — Human Wizard",
+					"oracle_text": "At the beginning of your upkeep, look at the top card of your library. You may reveal that card. If an instant or sorcery card is revealed this way, transform Delver of Secrets.",
+					"power": "1",
+					"toughness": "1",
+					"artist": "Nils Hamm"
+				},
+				{
+					"name": "Insectile Aberration",
+					"mana_cost": "",
+					"type_line": "Creature — Human Insect",
+					"oracle_text": "Flying",
+					"color_indicator": ["U"],
+					"power": "3",
+					"toughness": "2",
+					"artist": "Nils Hamm"
+				}
+			]
+		}`,
+		"Fire // Ice": `{
+			"name": "Fire // Ice",
+			"layout": "split",
+			"cmc": 4,
+			"mana_cost": "{1}{R} // {1}{U}",
+			"type_line": "Instant // Instant",
+			"set": "apc",
+			"set_name": "Apocalypse",
+			"rarity": "uncommon",
+			"artist": "Franz Vohwinkel",
+			"card_faces": [
+				{
+					"name": "Fire",
+					"mana_cost": "{1}{R}",
+					"type_line": "Instant",
+					"oracle_text": "Fire deals 2 damage divided as you choose among one or two targets."
+				},
+				{
+					"name": "Ice",
+					"mana_cost": "{1}{U}",
+					"type_line": "Instant",
+					"oracle_text": "Tap target permanent.\nDraw a card."
+				}
+			]
+		}`,
+	}
+	defer useTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if page, ok := pages[req.URL.Query().Get("exact")]; ok {
+			return response(req, http.StatusOK, page), nil
+		}
+		return response(req, http.StatusNotFound, "{}"), nil
+	}))()
+
+	p := ScryfallProvider{}
+	delver, err := p.GetCardForName(context.Background(), "Delver of Secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delver.Name != "Delver of Secrets" || delver.ManaCost != "{U}" || delver.Type != "Creature — Human Wizard" {
+		t.Errorf("front face fields not used: %+v", delver)
+	}
+	if delver.Power != "1" || delver.Toughness != "1" || delver.ConvertedManaCost != 1 {
+		t.Errorf("got %s/%s with cost %d, want 1/1 with cost 1", delver.Power, delver.Toughness, delver.ConvertedManaCost)
+	}
+	if back := delver.Faces[1]; back.Name != "Insectile Aberration" || back.ColorIndicator != "U" || back.Power != "3" {
+		t.Errorf("unexpected back face: %+v", back)
+	}
+	if !delver.IsMultiFaced() || delver.IsSplit() {
+		t.Errorf("IsMultiFaced = %t, IsSplit = %t; want true, false", delver.IsMultiFaced(), delver.IsSplit())
+	}
+
+	fireIce, err := p.GetCardForName(context.Background(), "Fire // Ice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fireIce.IsSplit() {
+		t.Error("Fire // Ice is not split")
+	}
+	if fireIce.ManaCost != "{1}{R}{1}{U}" || fireIce.ConvertedManaCost != 4 {
+		t.Errorf("got cost %s (%d), want {1}{R}{1}{U} (4)", fireIce.ManaCost, fireIce.ConvertedManaCost)
+	}
+	if fireIce.Faces[1].Text != "Tap target permanent.\nDraw a card." {
+		t.Errorf("got Ice text %q", fireIce.Faces[1].Text)
+	}
+}
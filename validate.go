@@ -0,0 +1,495 @@
+package mtg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+type ErrCardLimitExceeded struct {
+	Card string
+}
+
+func (e ErrCardLimitExceeded) Error() string {
+	return "too many copies of: " + e.Card
+}
+
+// ErrCardBanned is returned when a card is banned in the format being
+// validated, according to ValidateRules.Legalities.
+type ErrCardBanned struct {
+	Card   string
+	Format Format
+}
+
+func (e ErrCardBanned) Error() string {
+	return "card " + e.Card + " is banned in " + e.Format.String()
+}
+
+// ErrCompanionNotInSideboard is returned when a deck has a companion that
+// isn't in its sideboard.
+var ErrCompanionNotInSideboard = errors.New("companion is not in the sideboard")
+
+// ErrCompanionRestriction is returned by ValidateCompanion when a deck
+// doesn't meet its companion's deckbuilding restriction. Err is the error
+// returned by the restriction.
+type ErrCompanionRestriction struct {
+	Card string
+	Err  error
+}
+
+func (e ErrCompanionRestriction) Error() string {
+	return "deck does not meet the restriction of companion " + e.Card + ": " + e.Err.Error()
+}
+
+func (e ErrCompanionRestriction) Unwrap() error {
+	return e.Err
+}
+
+// ErrCardRestricted is returned when a deck has more than one copy of a card
+// that is restricted in the format being validated, according to
+// ValidateRules.Legalities.
+type ErrCardRestricted struct {
+	Card   string
+	Format Format
+}
+
+func (e ErrCardRestricted) Error() string {
+	return "card " + e.Card + " is restricted in " + e.Format.String()
+}
+
+// ErrCardNotLegal is returned when a card isn't part of the card pool of
+// the format being validated, according to ValidateRules.Legalities.
+type ErrCardNotLegal struct {
+	Card   string
+	Format Format
+}
+
+func (e ErrCardNotLegal) Error() string {
+	return "card " + e.Card + " is not legal in " + e.Format.String()
+}
+
+// ErrSideboardTooLarge is returned when a deck's sideboard has more cards
+// than its format allows. Size is the number of cards in the sideboard.
+type ErrSideboardTooLarge struct {
+	Size int
+}
+
+func (e ErrSideboardTooLarge) Error() string {
+	return fmt.Sprintf("sideboard is too large: %d cards", e.Size)
+}
+
+// ErrColorIdentity is returned when a card's color identity doesn't fit
+// within the color identity of the deck's commander.
+type ErrColorIdentity struct {
+	Card      string
+	Commander string
+}
+
+func (e ErrColorIdentity) Error() string {
+	return "card " + e.Card + " is outside the color identity of " + e.Commander
+}
+
+// ErrCommanderNotLegendary is returned when a deck's commander isn't
+// legendary in a format that requires it to be, such as Brawl.
+type ErrCommanderNotLegendary struct {
+	Card string
+}
+
+func (e ErrCommanderNotLegendary) Error() string {
+	return "commander " + e.Card + " is not legendary"
+}
+
+// ErrIllegalSet is returned when a card is from a set that isn't legal in
+// the format being validated. Set is the card's set code.
+type ErrIllegalSet struct {
+	Card string
+	Set  string
+}
+
+func (e ErrIllegalSet) Error() string {
+	return "card " + e.Card + " is from a set that is not legal: " + e.Set
+}
+
+// ErrRarity is returned when a card's rarity isn't allowed in the format
+// being validated, such as an uncommon in Pauper.
+type ErrRarity struct {
+	Card   string
+	Rarity string
+}
+
+func (e ErrRarity) Error() string {
+	return "card " + e.Card + " has a rarity that is not allowed: " + e.Rarity
+}
+
+// ErrUnknownRarity is returned when a format restricts rarity but a card's
+// rarity isn't known.
+type ErrUnknownRarity struct {
+	Card string
+}
+
+func (e ErrUnknownRarity) Error() string {
+	return "rarity of card " + e.Card + " is unknown"
+}
+
+type Format int
+
+const (
+	_ Format = iota
+	Constructed
+	Limited
+	Standard
+	Commander
+	Pauper
+	Vintage
+	Brawl
+	Modern
+	Legacy
+)
+
+// String returns the name of the format as Gatherer writes it, which is
+// also the key used for it in Legalities.
+func (f Format) String() string {
+	switch f {
+	case Constructed:
+		return "Constructed"
+	case Limited:
+		return "Limited"
+	case Standard:
+		return "Standard"
+	case Commander:
+		return "Commander"
+	case Pauper:
+		return "Pauper"
+	case Vintage:
+		return "Vintage"
+	case Brawl:
+		return "Brawl"
+	case Modern:
+		return "Modern"
+	case Legacy:
+		return "Legacy"
+	}
+	return "Format(" + strconv.Itoa(int(f)) + ")"
+}
+
+// Validate checks that the deck is legal in the given format, returning the
+// first problem found.
+func (d Deck) Validate(format Format) error {
+	if errs := d.ValidateAll(format); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// ValidateAll is like Validate, but returns every problem found instead of
+// stopping at the first one. Problems with individual cards are reported in
+// order of card name.
+func (d Deck) ValidateAll(format Format) []error {
+	return d.ValidateFormat(format, format.Rules())
+}
+
+// ValidateFormat is like ValidateAll, but checks the deck against the given
+// rules instead of the format's own. It is most useful for filling in the
+// fields of format.Rules() that have no built-in value, such as Sets and
+// Legalities:
+//
+//	rules := mtg.Standard.Rules()
+//	rules.Sets = map[string]bool{"DOM": true, "M19": true}
+//	rules.Legalities = legalities
+//	errs := deck.ValidateFormat(mtg.Standard, rules)
+func (d Deck) ValidateFormat(format Format, rules ValidateRules) (errs []error) {
+	switch format {
+	case Constructed, Limited:
+		errs = append(errs, d.checkRules(format, rules)...)
+
+	case Standard:
+		errs = append(errs, d.checkRules(format, rules)...)
+		errs = append(errs, d.checkSets(format, rules)...)
+
+	case Commander:
+		errs = append(errs, d.checkCommander(format, rules)...)
+
+	case Brawl:
+		errs = append(errs, d.checkCommander(format, rules)...)
+		if d.Commander != nil && !d.Commander.hasSupertype("Legendary") {
+			errs = append(errs, ErrCommanderNotLegendary{d.Commander.Name})
+		}
+		errs = append(errs, d.checkSets(format, rules)...)
+		if d.Commander != nil && !legalSet(*d.Commander, format, rules) {
+			errs = append(errs, ErrIllegalSet{d.Commander.Name, d.Commander.SetCode})
+		}
+
+	case Pauper:
+		errs = append(errs, d.checkRules(format, rules)...)
+		errs = append(errs, d.checkRarity(format, rules, "Common")...)
+
+	case Modern, Legacy, Vintage:
+		errs = append(errs, d.checkRules(format, rules)...)
+
+	default:
+		return append(errs, errors.New("unknown format"))
+	}
+	if d.Companion != nil && !d.hasSideboard(*d.Companion) {
+		errs = append(errs, ErrCompanionNotInSideboard)
+	}
+	return append(errs, d.checkLegalities(format, rules.Legalities)...)
+}
+
+// ValidateCompanion checks that the deck meets the deckbuilding restriction
+// of its companion. Since every companion's restriction is different, it is
+// given by the caller as restriction, which should return an error
+// describing why the deck doesn't meet it, or nil if it does. The companion
+// must also be in the sideboard. If the deck has no companion, nil is
+// returned.
+func (d Deck) ValidateCompanion(restriction func(Deck) error) error {
+	if d.Companion == nil {
+		return nil
+	}
+	if !d.hasSideboard(*d.Companion) {
+		return ErrCompanionNotInSideboard
+	}
+	if err := restriction(d); err != nil {
+		return ErrCompanionRestriction{d.Companion.Name, err}
+	}
+	return nil
+}
+
+// hasSideboard reports whether the sideboard holds a card Equal to card.
+func (d Deck) hasSideboard(card Card) bool {
+	_, ok := d.Sideboard[matchingCard(d.Sideboard, card)]
+	return ok
+}
+
+// ValidateRules holds the deck construction rules of a format. A zero
+// field means there is no limit.
+type ValidateRules struct {
+	// MinSize is the minimum number of cards in the main deck.
+	MinSize int
+	// MaxCopies is the maximum number of copies of any card other than a
+	// basic land, across the main deck and sideboard.
+	MaxCopies int
+	// MaxSideboard is the maximum number of cards in the sideboard.
+	MaxSideboard int
+	// Sets holds the codes of the sets that are legal in the format, as
+	// they appear in Card.SetCode, such as "DOM". It is only checked for
+	// Standard, Brawl, and custom rules, and only if it isn't empty; no
+	// format has a built-in value, since the legal sets change over time.
+	Sets map[string]bool
+	// Legalities holds the legalities of cards, keyed by card name, as
+	// returned by FetchLegalities. Cards that are banned in the format or
+	// not legal in it at all are rejected, and cards that are restricted
+	// in it are limited to one copy. Cards without an entry aren't
+	// checked. ValidateCustom ignores it, since Gatherer only lists
+	// legalities for the built-in formats.
+	Legalities map[string]Legalities
+}
+
+// Rules returns the deck construction rules of the format. For Commander
+// and Brawl, whose deck size depends on the commander, MinSize counts the
+// commander even if it isn't in the main deck.
+func (f Format) Rules() ValidateRules {
+	switch f {
+	case Constructed, Standard, Pauper, Modern, Legacy, Vintage:
+		return ValidateRules{MinSize: 60, MaxCopies: 4, MaxSideboard: 15}
+	case Limited:
+		return ValidateRules{MinSize: 40}
+	case Commander:
+		return ValidateRules{MinSize: 100, MaxCopies: 1}
+	case Brawl:
+		return ValidateRules{MinSize: 60, MaxCopies: 1}
+	}
+	return ValidateRules{}
+}
+
+// ValidateCustom is like ValidateAll, but checks the deck against the given
+// rules instead of those of a built-in format, for casual and homebrew
+// formats. If rules.Sets isn't empty, every card must also be from one of
+// its sets.
+func (d Deck) ValidateCustom(rules ValidateRules) []error {
+	rules.Legalities = nil
+	return append(d.checkRules(0, rules), d.checkSets(0, rules)...)
+}
+
+// checkRules checks the deck against rules. Copies of cards restricted in
+// format are limited to one.
+func (d Deck) checkRules(format Format, rules ValidateRules) (errs []error) {
+	if rules.MinSize > 0 && d.Size() < rules.MinSize {
+		errs = append(errs, ErrDeckTooSmall)
+	}
+	if rules.MaxSideboard > 0 {
+		errs = append(errs, d.checkSideboard(rules.MaxSideboard)...)
+	}
+	if rules.MaxCopies > 0 {
+		errs = append(errs, d.checkCopies(format, rules)...)
+	}
+	return errs
+}
+
+// checkCommander checks the rules shared by Commander and its variants:
+// the deck has a commander, holds exactly rules.MinSize cards including the
+// commander, has no more than rules.MaxCopies copies of any card, and fits
+// within the commander's color identity.
+func (d Deck) checkCommander(format Format, rules ValidateRules) (errs []error) {
+	n := d.Size()
+	if d.Commander == nil {
+		errs = append(errs, ErrNoCommander)
+	} else if _, ok := d.Main[matchingCard(d.Main, *d.Commander)]; !ok {
+		n++
+	}
+	if n < rules.MinSize {
+		errs = append(errs, ErrDeckTooSmall)
+	}
+	if n > rules.MinSize {
+		errs = append(errs, ErrDeckTooLarge)
+	}
+	errs = append(errs, d.checkCopies(format, rules)...)
+	if d.Commander != nil {
+		errs = append(errs, d.checkColorIdentity(*d.Commander)...)
+	}
+	return errs
+}
+
+// checkSideboard checks that the sideboard contains no more than max cards.
+func (d Deck) checkSideboard(max int) []error {
+	if size := d.SideboardSize(); size > max {
+		return []error{ErrSideboardTooLarge{size}}
+	}
+	return nil
+}
+
+// checkCopies checks that no nonbasic card has more than rules.MaxCopies
+// copies across the main deck and sideboard, or more than one if it's
+// restricted in format, as cards in Vintage can be.
+func (d Deck) checkCopies(format Format, rules ValidateRules) (errs []error) {
+	copies := d.copies()
+	for _, name := range sortedNames(copies) {
+		switch {
+		case copies[name] > rules.MaxCopies:
+			errs = append(errs, ErrCardLimitExceeded{name})
+		case copies[name] > 1 && legality(rules.Legalities, name, format) == "Restricted":
+			errs = append(errs, ErrCardRestricted{name, format})
+		}
+	}
+	return errs
+}
+
+// checkLegalities checks that every card in the main deck or sideboard
+// with an entry in legalities is legal in format, and not banned.
+// Constructed and Limited have no card pool, so they aren't checked.
+func (d Deck) checkLegalities(format Format, legalities map[string]Legalities) (errs []error) {
+	if len(legalities) == 0 || format == Constructed || format == Limited {
+		return nil
+	}
+	for _, name := range sortedNames(d.copies()) {
+		if _, ok := legalities[name]; !ok {
+			continue
+		}
+		switch legality(legalities, name, format) {
+		case "":
+			errs = append(errs, ErrCardNotLegal{name, format})
+		case "Banned":
+			errs = append(errs, ErrCardBanned{name, format})
+		}
+	}
+	return errs
+}
+
+// legality returns the status of the named card in format according to
+// legalities, or an empty string if it isn't known.
+func legality(legalities map[string]Legalities, name string, format Format) string {
+	return legalities[name][format.String()]
+}
+
+// sortedNames returns the keys of counts in sorted order.
+func sortedNames(counts map[string]int) []string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkSets checks that every card in the deck is from one of the sets in
+// rules.Sets, as decided by legalSet. If it is empty, nothing is checked.
+func (d Deck) checkSets(format Format, rules ValidateRules) (errs []error) {
+	if len(rules.Sets) == 0 {
+		return nil
+	}
+	for _, cards := range []map[Card]int{d.Main, d.Sideboard} {
+		for _, card := range sortedCards(cards) {
+			if !legalSet(card, format, rules) {
+				errs = append(errs, ErrIllegalSet{card.Name, card.SetCode})
+			}
+		}
+	}
+	return errs
+}
+
+// legalSet reports whether card may be played in format given the legal
+// sets in rules. Basic lands are always allowed. Only one printing of each
+// card is resolved, usually the newest (see PreferredSet), so a card from
+// another set is still allowed if rules.Legalities lists it as legal in
+// format, as it does for cards reprinted in a legal set.
+func legalSet(card Card, format Format, rules ValidateRules) bool {
+	if len(rules.Sets) == 0 || card.IsBasicLand() || rules.Sets[card.SetCode] {
+		return true
+	}
+	return legality(rules.Legalities, card.Name, format) == "Legal"
+}
+
+// checkRarity checks that every card in the deck has the given rarity.
+// Basic lands are exempt, since Gatherer doesn't always list them as
+// common. As with legalSet, only one printing of each card is resolved,
+// so a card of another rarity is still allowed if rules.Legalities lists
+// it as legal in format, as it does for a common reprinted at a higher
+// rarity.
+func (d Deck) checkRarity(format Format, rules ValidateRules, rarity string) (errs []error) {
+	for _, cards := range []map[Card]int{d.Main, d.Sideboard} {
+		for _, card := range sortedCards(cards) {
+			switch {
+			case card.IsBasicLand() || card.Rarity == rarity:
+			case legality(rules.Legalities, card.Name, format) == "Legal":
+			case card.Rarity == "":
+				errs = append(errs, ErrUnknownRarity{card.Name})
+			default:
+				errs = append(errs, ErrRarity{card.Name, card.Rarity})
+			}
+		}
+	}
+	return errs
+}
+
+// checkColorIdentity checks that every card in the main deck fits within
+// the color identity of commander.
+func (d Deck) checkColorIdentity(commander Card) (errs []error) {
+	identity := make(map[string]bool)
+	for _, color := range commander.ColorIdentity() {
+		identity[color] = true
+	}
+	for _, card := range sortedCards(d.Main) {
+		for _, color := range card.ColorIdentity() {
+			if !identity[color] {
+				errs = append(errs, ErrColorIdentity{card.Name, commander.Name})
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// copies returns the number of copies of each card across both the main
+// deck and the sideboard, keyed by name so that different printings of
+// the same card are counted together. Basic lands are excluded.
+func (d Deck) copies() map[string]int {
+	copies := make(map[string]int)
+	for _, cards := range []map[Card]int{d.Main, d.Sideboard} {
+		for card, count := range cards {
+			if !card.IsBasicLand() {
+				copies[card.Name] += count
+			}
+		}
+	}
+	return copies
+}
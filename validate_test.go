@@ -0,0 +1,102 @@
+package mtg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateSets(t *testing.T) {
+	var (
+		forest = Card{Name: "Forest", Type: "Basic Land — Forest", SetCode: "LEA"}
+		elves  = Card{Name: "Llanowar Elves", Type: "Creature — Elf Druid", SetCode: "DOM"}
+		opt    = Card{Name: "Opt", Type: "Instant", SetCode: "XLN"}
+		deck   = Deck{Main: map[Card]int{forest: 52, elves: 4, opt: 4}}
+	)
+	rules := Standard.Rules()
+	rules.Sets = map[string]bool{"DOM": true}
+
+	want := []error{ErrIllegalSet{"Opt", "XLN"}}
+	if errs := deck.ValidateFormat(Standard, rules); !reflect.DeepEqual(errs, want) {
+		t.Errorf("got %v, want %v", errs, want)
+	}
+
+	// A card resolved to a printing from an older set is still allowed if
+	// it's legal, as it would be if it were reprinted in a legal set.
+	rules.Legalities = map[string]Legalities{"Opt": {"Standard": "Legal"}}
+	if errs := deck.ValidateFormat(Standard, rules); len(errs) > 0 {
+		t.Errorf("got %v, want no errors", errs)
+	}
+}
+
+func TestValidateLegalities(t *testing.T) {
+	var (
+		island = Card{Name: "Island", Type: "Basic Land — Island"}
+		lotus  = Card{Name: "Black Lotus", Type: "Artifact"}
+		ritual = Card{Name: "Dark Ritual", Type: "Instant"}
+		oath   = Card{Name: "Oath of Druids", Type: "Enchantment"}
+		deck   = Deck{Main: map[Card]int{island: 52, lotus: 2, ritual: 4, oath: 2}}
+	)
+	rules := Vintage.Rules()
+	rules.Legalities = map[string]Legalities{
+		"Black Lotus":    {"Vintage": "Restricted"},
+		"Oath of Druids": {"Vintage": "Banned"},
+	}
+
+	want := []error{ErrCardRestricted{"Black Lotus", Vintage}, ErrCardBanned{"Oath of Druids", Vintage}}
+	if errs := deck.ValidateFormat(Vintage, rules); !reflect.DeepEqual(errs, want) {
+		t.Errorf("got %v, want %v", errs, want)
+	}
+	if errs := deck.ValidateAll(Vintage); len(errs) > 0 {
+		t.Errorf("got %v without legalities, want no errors", errs)
+	}
+}
+
+func TestValidatePauperReprint(t *testing.T) {
+	var (
+		island = Card{Name: "Island", Type: "Basic Land — Island"}
+		spell  = Card{Name: "Counterspell", Type: "Instant", Rarity: "Uncommon"}
+		deck   = Deck{Main: map[Card]int{island: 56, spell: 4}}
+	)
+	rules := Pauper.Rules()
+
+	want := []error{ErrRarity{"Counterspell", "Uncommon"}}
+	if errs := deck.ValidateFormat(Pauper, rules); !reflect.DeepEqual(errs, want) {
+		t.Errorf("got %v, want %v", errs, want)
+	}
+
+	// Counterspell has been printed at common, so it's legal in Pauper even
+	// though the printing that was resolved is an uncommon.
+	rules.Legalities = map[string]Legalities{"Counterspell": {"Pauper": "Legal"}}
+	if errs := deck.ValidateFormat(Pauper, rules); len(errs) > 0 {
+		t.Errorf("got %v, want no errors", errs)
+	}
+}
+
+func TestValidateCommanderInMain(t *testing.T) {
+	var (
+		inMain    = Card{MultiverseID: 386611, Name: "Omnath, Locus of Mana", Type: "Legendary Creature — Elemental", ManaCost: "{2}{G}"}
+		commander = Card{Name: "Omnath, Locus of Mana", Type: "Legendary Creature — Elemental", ManaCost: "{2}{G}"}
+		forest    = Card{Name: "Forest", Type: "Basic Land — Forest"}
+		deck      = Deck{Main: map[Card]int{inMain: 1, forest: 99}, Commander: &commander}
+	)
+	// The commander lacks a MultiverseID, but is Equal to the copy in the
+	// main deck, so it mustn't be counted twice.
+	if errs := deck.ValidateAll(Commander); len(errs) > 0 {
+		t.Errorf("got %v, want no errors", errs)
+	}
+}
+
+func TestValidateCustomSets(t *testing.T) {
+	var (
+		forest = Card{Name: "Forest", Type: "Basic Land — Forest", SetCode: "LEA"}
+		elves  = Card{Name: "Llanowar Elves", Type: "Creature — Elf Druid", SetCode: "DOM"}
+		opt    = Card{Name: "Opt", Type: "Instant", SetCode: "XLN"}
+		deck   = Deck{Main: map[Card]int{forest: 12, elves: 4, opt: 4}}
+	)
+	rules := ValidateRules{MinSize: 20, MaxCopies: 4, Sets: map[string]bool{"DOM": true}}
+
+	want := []error{ErrIllegalSet{"Opt", "XLN"}}
+	if errs := deck.ValidateCustom(rules); !reflect.DeepEqual(errs, want) {
+		t.Errorf("got %v, want %v", errs, want)
+	}
+}